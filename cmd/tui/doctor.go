@@ -0,0 +1,112 @@
+package main
+
+import (
+	"context"
+	"fmt"
+	"strings"
+
+	"github.com/FergusInLondon/PRList/internal/client/github"
+	"github.com/FergusInLondon/PRList/internal/pkg/config"
+	"github.com/mkideal/cli"
+)
+
+// DoctorArgs are the flags accepted by the `doctor` command.
+type DoctorArgs struct {
+	cli.Helper
+	Config  string `cli:"config" usage:"path to the config file (default: $XDG_CONFIG_HOME/prmon/config.yaml)"`
+	Account string `cli:"account" usage:"name of the configured account to diagnose (default: all accounts)"`
+}
+
+// doctorCommand diagnoses problems with the token of each account - i.e missing
+// scopes, or SSO authorization - which may result in Pull Requests going missing.
+var doctorCommand = &cli.Command{
+	Name: "doctor",
+	Desc: "diagnose problems with github tokens and their access",
+	Argv: func() interface{} { return new(DoctorArgs) },
+	Fn:   doctor,
+}
+
+func doctor(ctx *cli.Context) error {
+	params := ctx.Argv().(*DoctorArgs)
+
+	cfg, err := loadConfigFile(ctx, params.Config)
+	if err != nil {
+		return err
+	}
+
+	if err := cfg.Validate(); err != nil {
+		return err
+	}
+
+	accounts := cfg.ResolveAccounts()
+	if params.Account != "" {
+		account, err := loginAccount(cfg, params.Account)
+		if err != nil {
+			return err
+		}
+
+		accounts = []config.Account{account}
+	}
+
+	failures := 0
+	for _, account := range accounts {
+		results := diagnose(context.Background(), account)
+
+		title := account.Github.Host
+		if account.Name != "" {
+			title = fmt.Sprintf("%s (%s)", account.Name, account.Github.Host)
+		}
+
+		ctx.String("%s\n", title)
+		for _, result := range results {
+			ctx.String("  [%s] %s: %s\n", strings.ToUpper(result.Status), result.Name, result.Detail)
+			if result.Advice != "" {
+				ctx.String("         %s\n", result.Advice)
+			}
+
+			if result.Status == github.CHECK_FAIL {
+				failures++
+			}
+		}
+	}
+
+	if failures > 0 {
+		return fmt.Errorf("%d check(s) failed", failures)
+	}
+
+	return nil
+}
+
+// diagnose runs the checks for a single account; the token must be resolved
+// before any of the checks against Github can be made.
+func diagnose(ctx context.Context, account config.Account) []github.CheckResult {
+	endpoints, err := github.NewEndpoints(account.Github.Host, account.Github.BaseURL, account.Github.UploadURL)
+	if err != nil {
+		return []github.CheckResult{{Name: "host", Status: github.CHECK_FAIL, Detail: err.Error()}}
+	}
+
+	if account.Token.App != nil {
+		return []github.CheckResult{{
+			Name:   "token",
+			Status: github.CHECK_WARN,
+			Detail: "authenticating as a github app installation; only user tokens can be diagnosed",
+		}}
+	}
+
+	token, source, err := account.Token.Lookup(ctx, endpoints.Host)
+	if err != nil {
+		return []github.CheckResult{{
+			Name:   "token",
+			Status: github.CHECK_FAIL,
+			Detail: err.Error(),
+			Advice: "provide a token via one of the configured sources, or use the login command",
+		}}
+	}
+
+	doc, err := github.NewDoctor(ctx, endpoints, token, nil)
+	if err != nil {
+		return []github.CheckResult{{Name: "token", Status: github.CHECK_FAIL, Detail: err.Error()}}
+	}
+
+	return append([]github.CheckResult{{Name: "token", Status: github.CHECK_PASS, Detail: "found via " + source}}, doc.Run(ctx)...)
+}
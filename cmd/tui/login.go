@@ -0,0 +1,110 @@
+package main
+
+import (
+	"context"
+	"fmt"
+	"strings"
+
+	"github.com/FergusInLondon/PRList/internal/client/github"
+	"github.com/FergusInLondon/PRList/internal/pkg/config"
+	"github.com/mkideal/cli"
+)
+
+// LoginArgs are the flags accepted by the `login` command.
+type LoginArgs struct {
+	cli.Helper
+	Config   string `cli:"config" usage:"path to the config file (default: $XDG_CONFIG_HOME/prmon/config.yaml)"`
+	Account  string `cli:"account" usage:"name of the configured account to login to (default: the first account)"`
+	ClientID string `cli:"client-id" usage:"client id of the github oauth app used to login"`
+}
+
+// loginCommand generates a token via Github's OAuth device authorization flow,
+// and persists it in the configured token store; this avoids users having to
+// create a personal access token with the correct scopes themselves.
+var loginCommand = &cli.Command{
+	Name: "login",
+	Desc: "login to github via the browser, storing the generated token",
+	Argv: func() interface{} { return new(LoginArgs) },
+	Fn:   login,
+}
+
+func login(ctx *cli.Context) error {
+	params := ctx.Argv().(*LoginArgs)
+
+	cfg, err := loadConfigFile(ctx, params.Config)
+	if err != nil {
+		return err
+	}
+
+	account, err := loginAccount(cfg, params.Account)
+	if err != nil {
+		return err
+	}
+
+	if ctx.IsSet("--client-id") {
+		account.Github.ClientID = params.ClientID
+	}
+
+	if account.Github.ClientID == "" {
+		return fmt.Errorf("no oauth app client id configured; set github.client_id, or use --client-id")
+	}
+
+	store, err := account.Token.TokenStore()
+	if err != nil {
+		return err
+	}
+
+	endpoints, err := github.NewEndpoints(account.Github.Host, account.Github.BaseURL, account.Github.UploadURL)
+	if err != nil {
+		return err
+	}
+
+	loginCtx := context.Background()
+	flow := github.NewDeviceFlow(endpoints, account.Github.ClientID, github.REQUIRED_SCOPES, nil, nil)
+	code, err := flow.RequestCode(loginCtx)
+	if err != nil {
+		return err
+	}
+
+	ctx.String("First copy your one-time code: %s\n", code.UserCode)
+	ctx.String("Then open %s in your browser, and enter the code to authorise prmon.\n", code.VerificationURI)
+
+	token, err := flow.PollToken(loginCtx, code)
+	if err != nil {
+		return err
+	}
+
+	// Users - or their organisation's policies - can reduce the scopes granted.
+	username, scopes, err := github.TokenScopes(loginCtx, endpoints, token)
+	if err != nil {
+		return fmt.Errorf("unable to verify token: %w", err)
+	}
+
+	if missing := github.MissingScopes(scopes, github.REQUIRED_SCOPES); len(missing) > 0 {
+		return fmt.Errorf("token for '%s' is missing the required scopes: %s", username, strings.Join(missing, ", "))
+	}
+
+	if err := store.Store(loginCtx, endpoints.Host, token); err != nil {
+		return err
+	}
+
+	ctx.String("Logged in to %s as %s; token saved to %s.\n", endpoints.Host, username, store.Name())
+	return nil
+}
+
+// loginAccount returns the named account, or the first account if no name has
+// been provided.
+func loginAccount(cfg *config.Config, name string) (config.Account, error) {
+	accounts := cfg.ResolveAccounts()
+	if name == "" {
+		return accounts[0], nil
+	}
+
+	for _, account := range accounts {
+		if account.Name == name {
+			return account, nil
+		}
+	}
+
+	return config.Account{}, fmt.Errorf("no account named '%s' is configured", name)
+}
@@ -1,15 +1,21 @@
-// The `tui` app runs a basic TUI showing information on open assigned/created
-// PullRequests for the currently authenticated Github User. Additionally, it
-// has the functionality to open a browser window targeting a selected PR.
+// The `tui` app runs a basic TUI showing information on open assigned/created/
+// review-requested PullRequests for the currently authenticated Github User - or
+// Users, when multiple accounts are configured - as well as any PullRequests
+// matching saved searches. Additionally, it has the
+// functionality to open a browser window targeting a selected PR.
 //
 // It works by polling Github on a regular basis, comparing the returned results
 // with from the previous poll, and updating the TUI if there's any change.
 package main
 
 import (
+	"fmt"
+	"io/ioutil"
 	"os"
+	"strings"
 
 	"github.com/FergusInLondon/PRList/internal/client/github"
+	"github.com/FergusInLondon/PRList/internal/pkg/config"
 	"github.com/FergusInLondon/PRList/internal/pkg/tui"
 	"github.com/mkideal/cli"
 
@@ -17,63 +23,380 @@ import (
 	"time"
 )
 
+// CLIArgs are the flags accepted by the `tui` app; other than `config` and
+// `print-config`, all flags override values from the config file.
 type CLIArgs struct {
 	cli.Helper
-	Debug        bool   `cli:"debug" usage:"debug - poll more frequently" dft:"false"`
-	GithubToken  string `cli:"token" usage:"github personal access token" dft:"$GH_TOKEN"`
-	PollDuration int    `cli:"duration" usage:"duration - in minutes - to wait between polling github" dft:"5"`
+	Config       string   `cli:"config" usage:"path to the config file (default: $XDG_CONFIG_HOME/prmon/config.yaml)"`
+	PrintConfig  bool     `cli:"print-config" usage:"print the effective configuration, as yaml, and exit"`
+	Host         string   `cli:"host" usage:"github host to connect to - i.e a github enterprise server"`
+	BaseURL      string   `cli:"base-url" usage:"url of the github enterprise server api, if not https://<host>/api/v3/"`
+	UploadURL    string   `cli:"upload-url" usage:"url for github enterprise server uploads, if not derived from the base url"`
+	API          string   `cli:"api" usage:"github api to use - either v3 (REST) or v4 (GraphQL)"`
+	Concurrency  int      `cli:"concurrency" usage:"maximum number of concurrent requests to github"`
+	MaxItems     int      `cli:"max-items" usage:"maximum number of pull requests to retrieve per list"`
+	Debug        bool     `cli:"debug" usage:"debug - poll more frequently"`
+	TokenSources []string `cli:"token-source" usage:"where to look for the github token - env, keyring, gh, git or file - may be repeated"`
+	GithubToken  string   `cli:"token" usage:"github personal access token; visible in shell history, so prefer a token source"`
+	PollDuration int      `cli:"duration" usage:"duration - in minutes - to wait between polling github"`
+	Searches     []string `cli:"search" usage:"additional pane for a github search, as 'name=query' - may be repeated"`
 }
 
-func app(gh_token string, poll_duration int, debug bool) error {
-	waitMins := poll_duration
-	if debug {
+func app(ctx *cli.Context) error {
+	cfg, err := loadConfig(ctx)
+	if err != nil {
+		return err
+	}
+
+	if ctx.Argv().(*CLIArgs).PrintConfig {
+		out, err := cfg.Marshal()
+		if err != nil {
+			return err
+		}
+
+		ctx.String("%s", out)
+		return nil
+	}
+
+	waitMins := cfg.Poll.Interval
+	if cfg.Poll.Debug {
 		// Debug is quite literally "don't wait as much, and hopefully any errors
 		// will happen quicker/more frequently". Should likely implement some form
 		// of runtime logging.
 		waitMins = 1
 	}
 
-	ctx, stopper := context.WithCancel(context.Background())
+	pollCtx, stopper := context.WithCancel(context.Background())
 	defer stopper()
 
-	// Initialise our Github Poller, and generate the State required for the TUI
-	ghPoller := github.NewPoller(ctx, gh_token)
-	tuiController := tui.NewController(&tui.State{
-		GithubUsername: ghPoller.Username,
-		PollInterval:   waitMins,
-		Assigned:       ghPoller.AssignedPullRequests.Items,
-		Created:        ghPoller.CreatedPullRequests.Items,
-		LastSync:       ghPoller.LastPolled,
+	// Initialise a Github Poller per account, and generate the State required
+	// for the TUI
+	searches := savedSearches(cfg.Panes)
+	accounts := cfg.ResolveAccounts()
+	ghAccounts := make([]*github.Account, len(accounts))
+	for idx, account := range accounts {
+		ghAccounts[idx], err = newAccount(pollCtx, account, searches)
+		if err != nil {
+			return err
+		}
+
+		ghAccounts[idx].Poller.ClosedWindow = time.Duration(cfg.Poll.ClosedWindow) * time.Hour
+	}
+
+	ghPollers := github.NewPollerGroup(ghAccounts...)
+	accountStates := make([]*tui.AccountState, len(ghPollers.Accounts))
+	for idx, account := range ghPollers.Accounts {
+		accountStates[idx] = &tui.AccountState{
+			Username:  account.Poller.Username,
+			Host:      account.Host,
+			LastSync:  account.Poller.Snapshot().FetchedAt,
+			RateLimit: account.Poller.RateLimit(),
+		}
+	}
+
+	tuiController, err := tui.NewController(&tui.State{
+		PollInterval: waitMins,
+		Accounts:     accountStates,
+		Panes:        pullRequestPanes(ghPollers.Snapshot(), cfg.Panes, nil),
+	}, &tui.Options{
+		Columns: cfg.Columns,
+		Colours: tui.Colours{
+			Good:    cfg.Theme.Good,
+			Default: cfg.Theme.Default,
+			Bad:     cfg.Theme.Bad,
+		},
+		Keys: tui.KeyBindings{
+			Open:         cfg.Keys.Open,
+			NextPane:     cfg.Keys.NextPane,
+			PreviousPane: cfg.Keys.PreviousPane,
+			Refresh:      cfg.Keys.Refresh,
+		},
+		Refresh: ghPollers.Refresh,
 	})
+	if err != nil {
+		return err
+	}
+
+	// Glue together notifications from each Github Poller with the TUI Controller;
+	// the notifications from each account are handled independently, with the
+	// Panes always displaying the merged collections of all accounts. Should the
+	// TUI fall behind, pending notifications are coalesced rather than blocking
+	// the Pollers.
+	for idx, account := range ghPollers.Accounts {
+		go func(idx int, ghPoller *github.Poller, notifications <-chan github.Notification) {
+			for notification := range notifications {
+				account := &tui.AccountState{
+					Syncing:   notification.Syncing,
+					RateLimit: ghPoller.RateLimit(),
+				}
+
+				// A Notification without a Snapshot is only indicating that a poll
+				// has begun - unless it has failed.
+				switch {
+				case notification.Err != nil:
+					account.LastError = notification.Err
+				case notification.Snapshot != nil:
+					account.LastSync = notification.PolledAt
+					account.CacheStats = ghPoller.CacheStats()
+				}
+
+				state := &tui.State{Accounts: accountUpdate(len(ghPollers.Accounts), idx, account)}
 
-	// Glue together notifications from the Github Poller with the TUI Controller
-	go func(notifyChans *github.PollerNotificationChannels) {
-		ghPoller.Poll(notifyChans, (time.Duration(waitMins) * time.Minute))
-		for {
-			select {
-			case <-ctx.Done():
-				return
-			case latestTimestamp := <-notifyChans.LatestPollTimestamp:
-				tuiController.Update(&tui.State{
-					LastSync: latestTimestamp,
-				})
-			case <-notifyChans.NewDataAvailable:
 				// ! Bug: the timestamps associated with a PR in the TUI will
 				// ! become stale if there are no updates from Github..!
-				tuiController.Update(&tui.State{
-					Assigned: ghPoller.AssignedPullRequests.Items,
-					Created:  ghPoller.CreatedPullRequests.Items,
-				})
+				if notification.Changes != nil {
+					state.Panes = pullRequestPanes(ghPollers.Snapshot(), cfg.Panes, notification.Changes)
+				}
+
+				tuiController.Update(state)
+			}
+		}(idx, account.Poller, account.Poller.Subscribe(pollCtx, nil))
+	}
+
+	ghPollers.Poll(time.Duration(waitMins) * time.Minute)
+	return tuiController.Run(pollCtx)
+}
+
+// newAccount creates the Poller for a single Github account; verifying that the
+// host is reachable, and retrieving the initial set of Pull Requests.
+func newAccount(ctx context.Context, account config.Account, searches []github.SavedSearch) (*github.Account, error) {
+	endpoints, err := github.NewEndpoints(account.Github.Host, account.Github.BaseURL, account.Github.UploadURL)
+	if err != nil {
+		return nil, err
+	}
+
+	if err := github.CheckHost(ctx, endpoints); err != nil {
+		return nil, err
+	}
+
+	sourceConfig := github.SourceConfig{
+		Endpoints:   endpoints,
+		APIVersion:  account.Github.API,
+		Concurrency: account.Github.Concurrency,
+		MaxItems:    account.Github.MaxItems,
+		ReviewTeams: account.Github.ReviewTeams,
+	}
+
+	if app := account.Token.App; app != nil {
+		privateKey, err := ioutil.ReadFile(app.PrivateKey)
+		if err != nil {
+			return nil, fmt.Errorf("unable to read github app private key: %w", err)
+		}
+
+		sourceConfig.App = &github.AppCredentials{
+			ID:             app.ID,
+			InstallationID: app.InstallationID,
+			PrivateKey:     privateKey,
+		}
+	} else if sourceConfig.Token, err = account.Token.Resolve(ctx, endpoints.Host); err != nil {
+		return nil, err
+	}
+
+	ghSource, err := github.NewSource(ctx, sourceConfig)
+	if err != nil {
+		return nil, err
+	}
+
+	ghPoller, err := github.NewPoller(ctx, ghSource, searches, nil)
+	if err != nil {
+		return nil, err
+	}
+
+	return &github.Account{Name: account.Name, Host: endpoints.Host, Poller: ghPoller}, nil
+}
+
+// accountUpdate returns the Accounts for a `tui.State` which only updates the
+// account at `idx`.
+func accountUpdate(count, idx int, account *tui.AccountState) []*tui.AccountState {
+	accounts := make([]*tui.AccountState, count)
+	accounts[idx] = account
+	return accounts
+}
+
+// loadConfig loads the config file - from the default location, unless one is
+// provided - and then applies any flags which have been explicitly set.
+func loadConfig(ctx *cli.Context) (*config.Config, error) {
+	params := ctx.Argv().(*CLIArgs)
+
+	cfg, err := loadConfigFile(ctx, params.Config)
+	if err != nil {
+		return nil, err
+	}
+
+	if ctx.IsSet("--host") {
+		cfg.Github.Host = params.Host
+	}
+
+	if ctx.IsSet("--base-url") {
+		cfg.Github.BaseURL = params.BaseURL
+	}
+
+	if ctx.IsSet("--upload-url") {
+		cfg.Github.UploadURL = params.UploadURL
+	}
+
+	if ctx.IsSet("--api") {
+		cfg.Github.API = params.API
+	}
+
+	if ctx.IsSet("--concurrency") {
+		cfg.Github.Concurrency = params.Concurrency
+	}
+
+	if ctx.IsSet("--max-items") {
+		cfg.Github.MaxItems = params.MaxItems
+	}
+
+	if ctx.IsSet("--debug") {
+		cfg.Poll.Debug = params.Debug
+	}
+
+	if ctx.IsSet("--token-source") {
+		cfg.Token.Sources = params.TokenSources
+	}
+
+	if ctx.IsSet("--token") {
+		cfg.Token.Value = params.GithubToken
+	}
+
+	if ctx.IsSet("--duration") {
+		cfg.Poll.Interval = params.PollDuration
+	}
+
+	// Searches provided as flags are appended to those from the config file.
+	searches, err := parseSavedSearches(params.Searches)
+	if err != nil {
+		return nil, err
+	}
+
+	for _, search := range searches {
+		cfg.Panes = append(cfg.Panes, config.Pane{Name: search.Name, Query: search.Query})
+	}
+
+	if err := cfg.Validate(); err != nil {
+		return nil, err
+	}
+
+	return cfg, nil
+}
+
+// loadConfigFile loads the config file from `path` if the `config` flag has been
+// set, otherwise from the default location - where it may not exist.
+func loadConfigFile(ctx *cli.Context, path string) (*config.Config, error) {
+	if !ctx.IsSet("--config") {
+		defaultPath, err := config.DefaultPath()
+		if err != nil {
+			return nil, err
+		}
+
+		path = defaultPath
+	}
+
+	return config.Load(path, ctx.IsSet("--config"))
+}
+
+// savedSearches returns the searches required by any panes with a query.
+func savedSearches(panes []config.Pane) []github.SavedSearch {
+	searches := make([]github.SavedSearch, 0)
+	for _, pane := range panes {
+		if pane.Query != "" {
+			searches = append(searches, github.SavedSearch{Name: pane.Name, Query: pane.Query})
+		}
+	}
+
+	return searches
+}
+
+// pullRequestPanes generates the Panes displayed by the TUI from a Snapshot of
+// the merged collections of every account, in the order that they're configured; panes
+// with a query are matched - by position - with the Pollers' saved searches.
+// If `changes` are provided, then only the panes which have changed are
+// populated - the TUI leaves any pane without Pull Requests untouched.
+func pullRequestPanes(snapshot *github.Snapshot, panes []config.Pane, changes *github.PollChanges) []tui.Pane {
+	tuiPanes := make([]tui.Pane, 0, len(panes))
+	searchIdx := 0
+	updateAll := changes == nil
+
+	for _, pane := range panes {
+		tuiPane := tui.Pane{Title: pane.Title}
+		switch {
+		case pane.Query != "":
+			if updateAll || (searchIdx < len(changes.Searches) && !changes.Searches[searchIdx].Empty()) {
+				tuiPane.PullRequests = snapshot.Searches[searchIdx]
+			}
+			searchIdx++
+		case pane.Name == config.PANE_ASSIGNED:
+			if updateAll || !changes.Assigned.Empty() {
+				tuiPane.PullRequests = snapshot.Assigned
+			}
+		case pane.Name == config.PANE_CREATED:
+			if updateAll || !changes.Created.Empty() {
+				tuiPane.PullRequests = snapshot.Created
 			}
+		case pane.Name == config.PANE_REVIEW_REQUESTED:
+			if updateAll || !changes.ReviewRequested.Empty() {
+				tuiPane.PullRequests = snapshot.ReviewRequested
+			}
+		case pane.Name == config.PANE_RECENTLY_CLOSED:
+			tuiPane.Dimmed = true
+			if updateAll || !changes.RecentlyClosed.Empty() {
+				tuiPane.PullRequests = snapshot.RecentlyClosed
+			}
+		}
+
+		if tuiPane.Title == "" {
+			tuiPane.Title = defaultPaneTitle(pane)
+		}
+
+		tuiPanes = append(tuiPanes, tuiPane)
+	}
+
+	return tuiPanes
+}
+
+func defaultPaneTitle(pane config.Pane) string {
+	switch {
+	case pane.Query != "":
+		return pane.Name
+	case pane.Name == config.PANE_ASSIGNED:
+		return "Assigned Pull Requests"
+	case pane.Name == config.PANE_CREATED:
+		return "Created Pull Requests"
+	case pane.Name == config.PANE_RECENTLY_CLOSED:
+		return "Recently Closed Pull Requests"
+	}
+
+	return "Review Requested Pull Requests"
+}
+
+// parseSavedSearches parses searches provided as 'name=query'.
+func parseSavedSearches(definitions []string) ([]github.SavedSearch, error) {
+	searches := make([]github.SavedSearch, 0, len(definitions))
+	for _, definition := range definitions {
+		parts := strings.SplitN(definition, "=", 2)
+		if len(parts) != 2 || strings.TrimSpace(parts[0]) == "" || strings.TrimSpace(parts[1]) == "" {
+			return nil, fmt.Errorf("invalid search '%s'; expected 'name=query'", definition)
 		}
-	}(github.NewPollerNotificationChannels())
 
-	return tuiController.Run(ctx)
+		searches = append(searches, github.SavedSearch{
+			Name:  strings.TrimSpace(parts[0]),
+			Query: strings.TrimSpace(parts[1]),
+		})
+	}
+
+	return searches, nil
 }
 
 func main() {
-	os.Exit(cli.Run(new(CLIArgs), func(ctx *cli.Context) error {
-		params := ctx.Argv().(*CLIArgs)
-		return app(params.GithubToken, params.PollDuration, params.Debug)
-	}))
+	root := &cli.Command{
+		Name: "tui",
+		Desc: "monitor open github pull requests",
+		Argv: func() interface{} { return new(CLIArgs) },
+		Fn:   app,
+	}
+
+	if err := cli.Root(root, cli.Tree(loginCommand), cli.Tree(doctorCommand)).Run(os.Args[1:]); err != nil {
+		fmt.Fprintln(os.Stderr, err)
+		os.Exit(1)
+	}
 }
@@ -15,4 +15,5 @@ require (
 	github.com/rivo/tview v0.0.0-20201018122409-d551c850a743
 	github.com/uniplaces/carbon v0.1.6
 	golang.org/x/oauth2 v0.0.0-20200902213428-5d25da1a8d43
+	gopkg.in/yaml.v2 v2.3.0
 )
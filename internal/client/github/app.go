@@ -0,0 +1,258 @@
+package github
+
+import (
+	"context"
+	"crypto"
+	"crypto/rand"
+	"crypto/rsa"
+	"crypto/sha256"
+	"crypto/x509"
+	"encoding/base64"
+	"encoding/json"
+	"encoding/pem"
+	"errors"
+	"fmt"
+	"io"
+	"io/ioutil"
+	"net/http"
+	"strings"
+	"time"
+
+	"golang.org/x/oauth2"
+)
+
+const (
+	// APP_JWT_EXPIRY is the lifetime of the JWT used to authenticate as a Github
+	// App; Github permits a maximum of 10 minutes.
+	APP_JWT_EXPIRY = 9 * time.Minute
+	// APP_JWT_CLOCK_DRIFT is subtracted from the issue time of the JWT, in case
+	// the local clock is ahead of Github's.
+	APP_JWT_CLOCK_DRIFT = 60 * time.Second
+	// APP_TOKEN_REFRESH_MARGIN is how long before expiry that an installation
+	// token is refreshed.
+	APP_TOKEN_REFRESH_MARGIN = 5 * time.Minute
+)
+
+// AppCredentials are the credentials required to authenticate as an installation
+// of a Github App.
+type AppCredentials struct {
+	// ID of the Github App
+	ID int64
+	// ID of the installation of the App - i.e within an organisation
+	InstallationID int64
+	// PEM encoded private key of the App
+	PrivateKey []byte
+}
+
+// AppTokenSource is an `oauth2.TokenSource` which authenticates as a Github App,
+// via a JWT signed with the App's private key, and exchanges this for a token
+// for the App's installation. Installation tokens expire after an hour, so this
+// should be wrapped in an `oauth2.ReuseTokenSource`; the expiry of each token
+// is brought forward by `APP_TOKEN_REFRESH_MARGIN`, so it's refreshed early.
+type AppTokenSource struct {
+	ctx         context.Context
+	client      *http.Client
+	clock       Clock
+	endpoints   Endpoints
+	credentials AppCredentials
+	key         *rsa.PrivateKey
+}
+
+type installationToken struct {
+	Token     string    `json:"token"`
+	ExpiresAt time.Time `json:"expires_at"`
+}
+
+type app struct {
+	Slug string `json:"slug"`
+}
+
+// NewAppTokenSource parses the App's private key, and returns an `AppTokenSource`
+// for the Github instance. If an `http.Client` isn't provided then the default
+// client is used; likewise for the `Clock`.
+func NewAppTokenSource(ctx context.Context, endpoints Endpoints, credentials AppCredentials, client *http.Client, clock Clock) (*AppTokenSource, error) {
+	if client == nil {
+		client = http.DefaultClient
+	}
+
+	if clock == nil {
+		clock = realClock{}
+	}
+
+	key, err := parseAppPrivateKey(credentials.PrivateKey)
+	if err != nil {
+		return nil, fmt.Errorf("invalid github app private key: %w", err)
+	}
+
+	return &AppTokenSource{
+		ctx:         ctx,
+		client:      client,
+		clock:       clock,
+		endpoints:   endpoints,
+		credentials: credentials,
+		key:         key,
+	}, nil
+}
+
+// Token exchanges a freshly signed JWT for a new installation token.
+func (source *AppTokenSource) Token() (*oauth2.Token, error) {
+	var token installationToken
+	path := fmt.Sprintf("app/installations/%d/access_tokens", source.credentials.InstallationID)
+	if err := source.request(source.ctx, http.MethodPost, path, http.StatusCreated, &token); err != nil {
+		return nil, fmt.Errorf("unable to retrieve github app installation token: %w", err)
+	}
+
+	return &oauth2.Token{
+		AccessToken: token.Token,
+		TokenType:   "token",
+		Expiry:      token.ExpiresAt.Add(-APP_TOKEN_REFRESH_MARGIN),
+	}, nil
+}
+
+// Login returns the login of the App's bot user - i.e "prmon[bot]" - which is
+// what Github displays as the author of any actions performed by the App.
+func (source *AppTokenSource) Login(ctx context.Context) (string, error) {
+	var details app
+	if err := source.request(ctx, http.MethodGet, "app", http.StatusOK, &details); err != nil {
+		return "", fmt.Errorf("unable to retrieve github app: %w", err)
+	}
+
+	return details.Slug + "[bot]", nil
+}
+
+// JWT generates a JWT - signed with the App's private key - which authenticates
+// as the App itself.
+func (source *AppTokenSource) JWT() (string, error) {
+	now := source.clock.Now()
+	header, err := json.Marshal(map[string]string{"alg": "RS256", "typ": "JWT"})
+	if err != nil {
+		return "", err
+	}
+
+	claims, err := json.Marshal(map[string]int64{
+		"iat": now.Add(-APP_JWT_CLOCK_DRIFT).Unix(),
+		"exp": now.Add(APP_JWT_EXPIRY).Unix(),
+		"iss": source.credentials.ID,
+	})
+	if err != nil {
+		return "", err
+	}
+
+	unsigned := base64.RawURLEncoding.EncodeToString(header) + "." + base64.RawURLEncoding.EncodeToString(claims)
+	digest := sha256.Sum256([]byte(unsigned))
+	signature, err := rsa.SignPKCS1v15(rand.Reader, source.key, crypto.SHA256, digest[:])
+	if err != nil {
+		return "", err
+	}
+
+	return unsigned + "." + base64.RawURLEncoding.EncodeToString(signature), nil
+}
+
+func (source *AppTokenSource) request(ctx context.Context, method, path string, expectedStatus int, target interface{}) error {
+	jwt, err := source.JWT()
+	if err != nil {
+		return err
+	}
+
+	req, err := http.NewRequestWithContext(ctx, method, source.endpoints.BaseURL+path, nil)
+	if err != nil {
+		return err
+	}
+
+	req.Header.Set("Authorization", "Bearer "+jwt)
+	req.Header.Set("Accept", "application/vnd.github.v3+json")
+
+	resp, err := source.client.Do(req)
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode != expectedStatus {
+		// Github includes a message describing the failure - i.e an invalid
+		// installation ID - which is far more useful than the status alone.
+		var failure struct {
+			Message string `json:"message"`
+		}
+		body, _ := ioutil.ReadAll(io.LimitReader(resp.Body, 1<<16))
+		if json.Unmarshal(body, &failure) == nil && failure.Message != "" {
+			return fmt.Errorf("%s: %s", resp.Status, failure.Message)
+		}
+
+		return errors.New(resp.Status)
+	}
+
+	return json.NewDecoder(resp.Body).Decode(target)
+}
+
+func parseAppPrivateKey(privateKey []byte) (*rsa.PrivateKey, error) {
+	// Github generates PKCS#1 keys, although PKCS#8 keys are also accepted in
+	// case the key has been converted.
+	block, _ := pem.Decode(privateKey)
+	if block == nil {
+		return nil, errors.New("no PEM encoded key found")
+	}
+
+	if strings.Contains(block.Type, "RSA") {
+		return x509.ParsePKCS1PrivateKey(block.Bytes)
+	}
+
+	key, err := x509.ParsePKCS8PrivateKey(block.Bytes)
+	if err != nil {
+		return nil, err
+	}
+
+	rsaKey, isRSA := key.(*rsa.PrivateKey)
+	if !isRSA {
+		return nil, errors.New("key isn't an RSA key")
+	}
+
+	return rsaKey, nil
+}
+
+// InstallationSource wraps the `PullRequestSource` of a Github App installation.
+// An App isn't a user - and can't be assigned, create, or review Pull Requests -
+// so only the results of saved searches are available; the current user is the
+// App's bot user.
+type InstallationSource struct {
+	PullRequestSource
+	app *AppTokenSource
+}
+
+// NewInstallationSource returns an `InstallationSource` wrapping the source,
+// which must be authenticated via the `AppTokenSource`.
+func NewInstallationSource(source PullRequestSource, app *AppTokenSource) *InstallationSource {
+	return &InstallationSource{PullRequestSource: source, app: app}
+}
+
+// CurrentUser returns the login of the App's bot user.
+func (source *InstallationSource) CurrentUser(ctx context.Context) (string, error) {
+	return source.app.Login(ctx)
+}
+
+// PullRequests returns empty sets; see `InstallationSource`.
+func (source *InstallationSource) PullRequests(ctx context.Context) (*PullRequestSets, error) {
+	return &PullRequestSets{
+		Assigned:        []PullRequestSummary{},
+		Created:         []PullRequestSummary{},
+		ReviewRequested: []PullRequestSummary{},
+	}, nil
+}
+
+// RateLimit returns the rate limit status of the wrapped source, if known.
+func (source *InstallationSource) RateLimit() RateLimit {
+	if reporter, isReporter := source.PullRequestSource.(RateLimitReporter); isReporter {
+		return reporter.RateLimit()
+	}
+
+	return RateLimit{}
+}
+
+// CacheStats returns the cache statistics of the wrapped source, if known.
+func (source *InstallationSource) CacheStats() CacheStats {
+	if reporter, isReporter := source.PullRequestSource.(CacheStatsReporter); isReporter {
+		return reporter.CacheStats()
+	}
+
+	return CacheStats{}
+}
@@ -0,0 +1,315 @@
+package github
+
+import (
+	"context"
+	"crypto"
+	"crypto/ecdsa"
+	"crypto/elliptic"
+	"crypto/rand"
+	"crypto/rsa"
+	"crypto/sha256"
+	"crypto/x509"
+	"encoding/base64"
+	"encoding/json"
+	"encoding/pem"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"golang.org/x/oauth2"
+)
+
+var (
+	testAppKeyOnce sync.Once
+	testAppKey     *rsa.PrivateKey
+)
+
+// appKey returns an RSA key - shared between tests, as generation is slow - for
+// signing the JWTs of a Github App.
+func appKey(t *testing.T) *rsa.PrivateKey {
+	t.Helper()
+
+	testAppKeyOnce.Do(func() {
+		key, err := rsa.GenerateKey(rand.Reader, 2048)
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		testAppKey = key
+	})
+
+	return testAppKey
+}
+
+func pkcs1PEM(key *rsa.PrivateKey) []byte {
+	return pem.EncodeToMemory(&pem.Block{Type: "RSA PRIVATE KEY", Bytes: x509.MarshalPKCS1PrivateKey(key)})
+}
+
+// parseAppJWT checks the JWT is signed by `key` with RS256, and returns its
+// claims.
+func parseAppJWT(key *rsa.PrivateKey, jwt string) (map[string]int64, error) {
+	segments := strings.Split(jwt, ".")
+	if len(segments) != 3 {
+		return nil, fmt.Errorf("expected a JWT with three segments, found '%s'", jwt)
+	}
+
+	var header map[string]string
+	if err := decodeJWTSegment(segments[0], &header); err != nil || header["alg"] != "RS256" || header["typ"] != "JWT" {
+		return nil, fmt.Errorf("expected an RS256 JWT header, found %v (%v)", header, err)
+	}
+
+	signature, err := base64.RawURLEncoding.DecodeString(segments[2])
+	if err != nil {
+		return nil, err
+	}
+
+	digest := sha256.Sum256([]byte(segments[0] + "." + segments[1]))
+	if err := rsa.VerifyPKCS1v15(&key.PublicKey, crypto.SHA256, digest[:], signature); err != nil {
+		return nil, fmt.Errorf("expected the JWT to be signed by the App's key: %w", err)
+	}
+
+	var claims map[string]int64
+	if err := decodeJWTSegment(segments[1], &claims); err != nil {
+		return nil, err
+	}
+
+	return claims, nil
+}
+
+func decodeJWTSegment(segment string, target interface{}) error {
+	decoded, err := base64.RawURLEncoding.DecodeString(segment)
+	if err != nil {
+		return err
+	}
+
+	return json.Unmarshal(decoded, target)
+}
+
+// newTestAppTokenSource returns an `AppTokenSource` for installation 42 of App
+// 7, whose installation tokens - numbered sequentially - expire after
+// `lifetime`; it also returns the number of tokens issued.
+func newTestAppTokenSource(t *testing.T, clock Clock, lifetime time.Duration) (*AppTokenSource, func() int) {
+	t.Helper()
+
+	key := appKey(t)
+
+	var mutex sync.Mutex
+	issued := 0
+
+	mux := http.NewServeMux()
+	authorised := func(w http.ResponseWriter, r *http.Request) bool {
+		claims, err := parseAppJWT(key, strings.TrimPrefix(r.Header.Get("Authorization"), "Bearer "))
+		if err != nil {
+			t.Error(err)
+		}
+
+		if claims["iss"] != 7 {
+			w.WriteHeader(http.StatusUnauthorized)
+			writeJSON(t, w, map[string]interface{}{"message": "Integration not found"})
+			return false
+		}
+
+		return true
+	}
+
+	mux.HandleFunc("/app/installations/42/access_tokens", func(w http.ResponseWriter, r *http.Request) {
+		if r.Method != http.MethodPost || !authorised(w, r) {
+			return
+		}
+
+		mutex.Lock()
+		issued++
+		token := fmt.Sprintf("installation-%d", issued)
+		mutex.Unlock()
+
+		w.WriteHeader(http.StatusCreated)
+		writeJSON(t, w, map[string]interface{}{"token": token, "expires_at": time.Now().Add(lifetime)})
+	})
+
+	mux.HandleFunc("/app", func(w http.ResponseWriter, r *http.Request) {
+		if authorised(w, r) {
+			writeJSON(t, w, map[string]interface{}{"slug": "prmon"})
+		}
+	})
+
+	server := httptest.NewServer(mux)
+	t.Cleanup(server.Close)
+
+	credentials := AppCredentials{ID: 7, InstallationID: 42, PrivateKey: pkcs1PEM(key)}
+	source, err := NewAppTokenSource(context.Background(), Endpoints{BaseURL: server.URL + "/"}, credentials, server.Client(), clock)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	return source, func() int {
+		mutex.Lock()
+		defer mutex.Unlock()
+
+		return issued
+	}
+}
+
+func TestAppTokenSourceJWT(t *testing.T) {
+	clock := newFakeClock()
+	source, _ := newTestAppTokenSource(t, clock, time.Hour)
+
+	jwt, err := source.JWT()
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	claims, err := parseAppJWT(appKey(t), jwt)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	expected := map[string]int64{
+		"iat": clock.Now().Add(-APP_JWT_CLOCK_DRIFT).Unix(),
+		"exp": clock.Now().Add(APP_JWT_EXPIRY).Unix(),
+		"iss": 7,
+	}
+
+	for claim, value := range expected {
+		if claims[claim] != value {
+			t.Errorf("expected the claim %s to be %d, found %d", claim, value, claims[claim])
+		}
+	}
+}
+
+func TestAppTokenSourceToken(t *testing.T) {
+	source, issued := newTestAppTokenSource(t, nil, time.Hour)
+
+	token, err := source.Token()
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if token.AccessToken != "installation-1" || token.TokenType != "token" {
+		t.Fatalf("expected the installation token, found %+v", token)
+	}
+
+	// The token is treated as expiring before Github does so.
+	if until := time.Until(token.Expiry); until > time.Hour-APP_TOKEN_REFRESH_MARGIN || until < time.Hour-APP_TOKEN_REFRESH_MARGIN-time.Minute {
+		t.Errorf("expected the token to expire %s early, found it expires in %s", APP_TOKEN_REFRESH_MARGIN, until)
+	}
+
+	if issued() != 1 {
+		t.Errorf("expected a single token to be issued, found %d", issued())
+	}
+
+	source.credentials.InstallationID = 43
+	if _, err := source.Token(); err == nil || !strings.Contains(err.Error(), "404") {
+		t.Fatalf("expected an unknown installation to be reported, found %v", err)
+	}
+}
+
+func TestAppTokenSourceRefresh(t *testing.T) {
+	tests := []struct {
+		name     string
+		lifetime time.Duration
+		issued   int
+	}{
+		{name: "reused", lifetime: time.Hour, issued: 1},
+		{name: "reused until the refresh margin", lifetime: APP_TOKEN_REFRESH_MARGIN + time.Minute, issued: 1},
+		{name: "refreshed within the refresh margin", lifetime: APP_TOKEN_REFRESH_MARGIN - time.Minute, issued: 3},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			source, issued := newTestAppTokenSource(t, nil, test.lifetime)
+			reused := oauth2.ReuseTokenSource(nil, source)
+
+			for idx := 0; idx < 3; idx++ {
+				token, err := reused.Token()
+				if err != nil {
+					t.Fatal(err)
+				}
+
+				if expected := fmt.Sprintf("installation-%d", issued()); token.AccessToken != expected {
+					t.Fatalf("expected the latest token '%s', found '%s'", expected, token.AccessToken)
+				}
+			}
+
+			if issued() != test.issued {
+				t.Errorf("expected %d tokens to be issued, found %d", test.issued, issued())
+			}
+		})
+	}
+}
+
+func TestParseAppPrivateKey(t *testing.T) {
+	key := appKey(t)
+	pkcs8, err := x509.MarshalPKCS8PrivateKey(key)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	ecKey, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	ecPKCS8, err := x509.MarshalPKCS8PrivateKey(ecKey)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	tests := []struct {
+		name string
+		pem  []byte
+		err  string
+	}{
+		{name: "pkcs1", pem: pkcs1PEM(key)},
+		{name: "pkcs8", pem: pem.EncodeToMemory(&pem.Block{Type: "PRIVATE KEY", Bytes: pkcs8})},
+		{name: "not rsa", pem: pem.EncodeToMemory(&pem.Block{Type: "PRIVATE KEY", Bytes: ecPKCS8}), err: "key isn't an RSA key"},
+		{name: "not pem", pem: []byte("not a key"), err: "no PEM encoded key found"},
+		{name: "corrupt", pem: pem.EncodeToMemory(&pem.Block{Type: "RSA PRIVATE KEY", Bytes: []byte("corrupt")}), err: "asn1"},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			parsed, err := parseAppPrivateKey(test.pem)
+			if test.err != "" {
+				if err == nil || !strings.Contains(err.Error(), test.err) {
+					t.Fatalf("expected the error '%s', found %v", test.err, err)
+				}
+				return
+			}
+
+			if err != nil {
+				t.Fatal(err)
+			}
+
+			if !parsed.Equal(key) {
+				t.Fatal("expected the parsed key to match")
+			}
+		})
+	}
+}
+
+func TestInstallationSource(t *testing.T) {
+	app, _ := newTestAppTokenSource(t, nil, time.Hour)
+	source := NewInstallationSource(NewFakeSource("user", &PullRequestSets{}), app)
+
+	username, err := source.CurrentUser(context.Background())
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if username != "prmon[bot]" {
+		t.Fatalf("expected the App's bot user, found '%s'", username)
+	}
+
+	sets, err := source.PullRequests(context.Background())
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if len(sets.Assigned) > 0 || len(sets.Created) > 0 || len(sets.ReviewRequested) > 0 {
+		t.Fatalf("expected an App to have no Pull Requests of its own, found %+v", sets)
+	}
+}
@@ -0,0 +1,197 @@
+package github
+
+import (
+	"context"
+	"sync"
+	"time"
+)
+
+const (
+	// POLICY_COALESCE merges every buffered Notification - along with the new
+	// one - into a single Notification once a subscriber's buffer is full; so a
+	// slow subscriber doesn't miss any changes, but receives them in one go.
+	POLICY_COALESCE = "coalesce"
+	// POLICY_DROP discards new Notifications whilst a subscriber's buffer is
+	// full.
+	POLICY_DROP = "drop"
+
+	// NOTIFICATION_BUFFER_SIZE is the default number of Notifications buffered
+	// for each subscriber.
+	NOTIFICATION_BUFFER_SIZE = 16
+)
+
+// Notification describes the outcome of a single poll; or - if `Syncing` is set
+// - that a poll has begun.
+type Notification struct {
+	// Time at which the poll completed - or failed, or began
+	PolledAt time.Time
+	// Syncing is set when a poll begins; such a Notification is always followed
+	// by another describing the outcome. Unless coalesced with the outcome of
+	// previous polls, it contains nothing else.
+	Syncing bool
+	// Changes to each collection; nil if the poll failed, or nothing changed.
+	// When Notifications are coalesced the Diffs are concatenated, so the same
+	// Pull Request may be present more than once.
+	Changes *PollChanges
+	// Events observed by the poll, in the order they were observed
+	Events []Event
+	// Snapshot of the Poller's collections as of the poll; nil if it failed
+	Snapshot *Snapshot
+	// Err is set if the poll failed; the Poller will continue, and retry upon
+	// the next interval.
+	Err error
+}
+
+// SubscribeOptions configures the delivery of Notifications to a subscriber;
+// a nil `*SubscribeOptions` is equivalent to the zero value.
+type SubscribeOptions struct {
+	// Number of Notifications to buffer; defaults to `NOTIFICATION_BUFFER_SIZE`
+	Buffer int
+	// What to do once the buffer is full - either `POLICY_COALESCE` or
+	// `POLICY_DROP`; defaults to `POLICY_COALESCE`
+	Policy string
+}
+
+// broadcaster delivers Notifications to any number of subscribers, without ever
+// blocking the publisher; each subscriber has its own buffer, so a slow - or
+// departed - subscriber has no effect upon the others.
+type broadcaster struct {
+	mutex       sync.Mutex
+	subscribers map[chan Notification]string
+}
+
+func (broadcast *broadcaster) subscribe(ctx context.Context, options *SubscribeOptions) <-chan Notification {
+	if options == nil {
+		options = &SubscribeOptions{}
+	}
+
+	buffer := options.Buffer
+	if buffer <= 0 {
+		buffer = NOTIFICATION_BUFFER_SIZE
+	}
+
+	policy := options.Policy
+	if policy != POLICY_DROP {
+		policy = POLICY_COALESCE
+	}
+
+	notifications := make(chan Notification, buffer)
+
+	broadcast.mutex.Lock()
+	if broadcast.subscribers == nil {
+		broadcast.subscribers = make(map[chan Notification]string)
+	}
+	broadcast.subscribers[notifications] = policy
+	broadcast.mutex.Unlock()
+
+	// The channel is closed whilst holding the mutex, so there's no risk of a
+	// concurrent publish sending on a closed channel.
+	go func() {
+		<-ctx.Done()
+
+		broadcast.mutex.Lock()
+		defer broadcast.mutex.Unlock()
+		delete(broadcast.subscribers, notifications)
+		close(notifications)
+	}()
+
+	return notifications
+}
+
+func (broadcast *broadcaster) publish(notification Notification) {
+	broadcast.mutex.Lock()
+	defer broadcast.mutex.Unlock()
+
+	for subscriber, policy := range broadcast.subscribers {
+		select {
+		case subscriber <- notification:
+			continue
+		default:
+		}
+
+		if policy == POLICY_DROP {
+			continue
+		}
+
+		// Only the publisher sends - and it holds the mutex - so once the buffer
+		// has been drained there's guaranteed to be space for the merged
+		// Notification; even if the subscriber is receiving concurrently.
+		var merged *Notification
+		for drained := false; !drained; {
+			select {
+			case buffered := <-subscriber:
+				merged = coalesce(merged, buffered)
+			default:
+				drained = true
+			}
+		}
+
+		subscriber <- *coalesce(merged, notification)
+	}
+}
+
+// coalesce merges `next` into `merged`, which may be nil.
+func coalesce(merged *Notification, next Notification) *Notification {
+	if merged == nil {
+		return &next
+	}
+
+	// A poll beginning doesn't supersede the outcome of any previous polls.
+	if next.Syncing {
+		syncing := *merged
+		syncing.Syncing = true
+		return &syncing
+	}
+
+	events := make([]Event, 0, len(merged.Events)+len(next.Events))
+	events = append(append(events, merged.Events...), next.Events...)
+
+	snapshot := next.Snapshot
+	if snapshot == nil {
+		snapshot = merged.Snapshot
+	}
+
+	return &Notification{
+		PolledAt: next.PolledAt,
+		Changes:  coalesceChanges(merged.Changes, next.Changes),
+		Events:   events,
+		Snapshot: snapshot,
+		Err:      next.Err,
+	}
+}
+
+func coalesceChanges(previous, next *PollChanges) *PollChanges {
+	if previous == nil {
+		return next
+	}
+
+	if next == nil {
+		return previous
+	}
+
+	changes := &PollChanges{
+		Assigned:        previous.Assigned.concat(next.Assigned),
+		Created:         previous.Created.concat(next.Created),
+		ReviewRequested: previous.ReviewRequested.concat(next.ReviewRequested),
+		Searches:        make([]Diff, len(next.Searches)),
+		RecentlyClosed:  previous.RecentlyClosed.concat(next.RecentlyClosed),
+	}
+
+	for idx := range next.Searches {
+		if idx < len(previous.Searches) {
+			changes.Searches[idx] = previous.Searches[idx].concat(next.Searches[idx])
+		} else {
+			changes.Searches[idx] = next.Searches[idx]
+		}
+	}
+
+	return changes
+}
+
+func (diff Diff) concat(next Diff) Diff {
+	return Diff{
+		Added:    append(append([]PullRequestSummary(nil), diff.Added...), next.Added...),
+		Removed:  append(append([]PullRequestSummary(nil), diff.Removed...), next.Removed...),
+		Modified: append(append([]PullRequestChange(nil), diff.Modified...), next.Modified...),
+	}
+}
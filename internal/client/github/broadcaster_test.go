@@ -0,0 +1,199 @@
+package github
+
+import (
+	"context"
+	"errors"
+	"runtime"
+	"sync"
+	"testing"
+	"time"
+)
+
+// notificationAt returns a Notification distinguished by its PolledAt.
+func notificationAt(seconds int) Notification {
+	return Notification{PolledAt: time.Unix(int64(seconds), 0)}
+}
+
+func TestBroadcasterSlowSubscriberNeverBlocks(t *testing.T) {
+	for _, policy := range []string{POLICY_COALESCE, POLICY_DROP} {
+		t.Run(policy, func(t *testing.T) {
+			ctx, cancel := context.WithCancel(context.Background())
+			defer cancel()
+
+			var notifier broadcaster
+			notifier.subscribe(ctx, &SubscribeOptions{Buffer: 1, Policy: policy})
+			fast := notifier.subscribe(ctx, &SubscribeOptions{Buffer: 100})
+
+			published := make(chan struct{})
+			go func() {
+				defer close(published)
+				for idx := 0; idx < 100; idx++ {
+					notifier.publish(notificationAt(idx))
+				}
+			}()
+
+			select {
+			case <-published:
+			case <-time.After(5 * time.Second):
+				t.Fatal("publish blocked on a subscriber which isn't receiving")
+			}
+
+			if len(fast) != 100 {
+				t.Fatalf("expected the other subscriber to receive 100 notifications, received %d", len(fast))
+			}
+		})
+	}
+}
+
+func TestBroadcasterDropPolicy(t *testing.T) {
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+
+	var notifier broadcaster
+	notifications := notifier.subscribe(ctx, &SubscribeOptions{Buffer: 2, Policy: POLICY_DROP})
+	for idx := 0; idx < 5; idx++ {
+		notifier.publish(notificationAt(idx))
+	}
+
+	if len(notifications) != 2 {
+		t.Fatalf("expected 2 buffered notifications, found %d", len(notifications))
+	}
+
+	for idx := 0; idx < 2; idx++ {
+		if received := <-notifications; !received.PolledAt.Equal(notificationAt(idx).PolledAt) {
+			t.Fatalf("expected notification %d to be retained, received %s", idx, received.PolledAt)
+		}
+	}
+
+	// Once there's space in the buffer, notifications are delivered again.
+	notifier.publish(notificationAt(5))
+	if received := <-notifications; !received.PolledAt.Equal(notificationAt(5).PolledAt) {
+		t.Fatalf("expected the latest notification, received %s", received.PolledAt)
+	}
+}
+
+func TestBroadcasterCoalescePolicy(t *testing.T) {
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+
+	a := PullRequestSummary{URL: "https://github.com/o/r/pull/1"}
+	b := PullRequestSummary{URL: "https://github.com/o/r/pull/2"}
+	first, second := &Snapshot{Sequence: 1}, &Snapshot{Sequence: 2}
+	failure := errors.New("poll failed")
+
+	var notifier broadcaster
+	notifications := notifier.subscribe(ctx, &SubscribeOptions{Buffer: 1, Policy: POLICY_COALESCE})
+	notifier.publish(Notification{
+		PolledAt: time.Unix(1, 0),
+		Changes:  &PollChanges{Assigned: Diff{Added: []PullRequestSummary{a}}},
+		Events:   []Event{{Type: EVENT_OPENED, PullRequest: a}},
+		Snapshot: first,
+	})
+	notifier.publish(Notification{
+		PolledAt: time.Unix(2, 0),
+		Changes:  &PollChanges{Created: Diff{Added: []PullRequestSummary{b}}},
+		Events:   []Event{{Type: EVENT_OPENED, PullRequest: b}},
+		Snapshot: second,
+	})
+	notifier.publish(Notification{PolledAt: time.Unix(3, 0), Syncing: true})
+	notifier.publish(Notification{PolledAt: time.Unix(4, 0), Err: failure})
+
+	if len(notifications) != 1 {
+		t.Fatalf("expected the notifications to be coalesced into 1, found %d", len(notifications))
+	}
+
+	merged := <-notifications
+	if !merged.PolledAt.Equal(time.Unix(4, 0)) {
+		t.Errorf("expected the newest PolledAt, found %s", merged.PolledAt)
+	}
+
+	if merged.Syncing {
+		t.Error("expected the outcome of the poll to supersede it syncing")
+	}
+
+	if merged.Err != failure {
+		t.Errorf("expected the newest error, found %v", merged.Err)
+	}
+
+	if merged.Snapshot != second {
+		t.Errorf("expected the newest snapshot, found %+v", merged.Snapshot)
+	}
+
+	if merged.Changes == nil || len(merged.Changes.Assigned.Added) != 1 || len(merged.Changes.Created.Added) != 1 {
+		t.Errorf("expected the changes of both polls, found %+v", merged.Changes)
+	}
+
+	if len(merged.Events) != 2 || merged.Events[0].PullRequest.Key() != a.Key() || merged.Events[1].PullRequest.Key() != b.Key() {
+		t.Errorf("expected the events of both polls in order, found %+v", merged.Events)
+	}
+}
+
+func TestBroadcasterCoalesceKeepsSyncing(t *testing.T) {
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+
+	snapshot := &Snapshot{Sequence: 1}
+
+	var notifier broadcaster
+	notifications := notifier.subscribe(ctx, &SubscribeOptions{Buffer: 1})
+	notifier.publish(Notification{PolledAt: time.Unix(1, 0), Snapshot: snapshot})
+	notifier.publish(Notification{PolledAt: time.Unix(2, 0), Syncing: true})
+
+	merged := <-notifications
+	if !merged.Syncing || merged.Snapshot != snapshot || !merged.PolledAt.Equal(time.Unix(1, 0)) {
+		t.Fatalf("expected the previous outcome to be retained whilst syncing, found %+v", merged)
+	}
+}
+
+func TestBroadcasterUnsubscribesOnCancel(t *testing.T) {
+	var notifier broadcaster
+
+	// Publish continuously whilst subscribers come and go; a send on a closed
+	// channel would panic.
+	stop := make(chan struct{})
+	var publishers sync.WaitGroup
+	for worker := 0; worker < 4; worker++ {
+		publishers.Add(1)
+		go func() {
+			defer publishers.Done()
+			for idx := 0; ; idx++ {
+				select {
+				case <-stop:
+					return
+				default:
+					notifier.publish(notificationAt(idx))
+					runtime.Gosched()
+				}
+			}
+		}()
+	}
+
+	for idx := 0; idx < 100; idx++ {
+		ctx, cancel := context.WithCancel(context.Background())
+		notifications := notifier.subscribe(ctx, &SubscribeOptions{Buffer: 1 + idx%3, Policy: []string{POLICY_COALESCE, POLICY_DROP}[idx%2]})
+		<-notifications
+		cancel()
+
+		closed := make(chan struct{})
+		go func() {
+			defer close(closed)
+			for range notifications {
+			}
+		}()
+
+		select {
+		case <-closed:
+		case <-time.After(5 * time.Second):
+			t.Fatal("expected the channel to be closed once the context was cancelled")
+		}
+	}
+
+	close(stop)
+	publishers.Wait()
+
+	notifier.mutex.Lock()
+	defer notifier.mutex.Unlock()
+	if len(notifier.subscribers) != 0 {
+		t.Fatalf("expected every subscriber to be removed, found %d", len(notifier.subscribers))
+	}
+}
@@ -0,0 +1,197 @@
+package github
+
+import (
+	"bufio"
+	"bytes"
+	"container/list"
+	"io/ioutil"
+	"net/http"
+	"net/http/httputil"
+	"sync"
+)
+
+// DEFAULT_CACHE_ENTRIES is the maximum number of responses retained by a
+// `CachingTransport`, unless otherwise configured; this comfortably exceeds the
+// number of requests made by a typical poll.
+const DEFAULT_CACHE_ENTRIES = 4096
+
+// CacheStats contains the number of requests served by a `CachingTransport`;
+// a hit is a request that resulted in a `304 Not Modified` response from
+// Github, whereas a miss is a request that required a full response.
+type CacheStats struct {
+	Hits   int
+	Misses int
+}
+
+// HitRatio returns the proportion of requests that were served from cache.
+func (stats CacheStats) HitRatio() float64 {
+	total := stats.Hits + stats.Misses
+	if total == 0 {
+		return 0
+	}
+
+	return float64(stats.Hits) / float64(total)
+}
+
+// CacheStatsReporter is implemented by any `PullRequestSource` which makes
+// requests via a `CachingTransport`.
+type CacheStatsReporter interface {
+	// CacheStats returns the hit/miss counts for the underlying cache.
+	CacheStats() CacheStats
+}
+
+// cacheEntry is a previously received response, along with the validators
+// required to make a conditional request for it.
+type cacheEntry struct {
+	key          string
+	etag         string
+	lastModified string
+	response     []byte
+}
+
+// CachingTransport is a `http.RoundTripper` which makes conditional requests
+// for any previously seen URLs, via the `ETag` and `Last-Modified` validators.
+// When Github responds with `304 Not Modified` the cached response is replayed;
+// these responses don't count towards the rate limit, so polling is cheap when
+// nothing has changed. Only GET requests are cached; once `MaxEntries` responses
+// are cached, the least recently requested are evicted.
+type CachingTransport struct {
+	mutex   sync.Mutex
+	base    http.RoundTripper
+	entries map[string]*list.Element
+	// Cached entries, ordered from the most to the least recently requested
+	recency *list.List
+	stats   CacheStats
+	// Maximum number of responses cached; zero or less is unbounded
+	MaxEntries int
+}
+
+// NewCachingTransport returns a `CachingTransport` which makes requests via
+// `base`; if `base` is nil, then `http.DefaultTransport` will be used.
+func NewCachingTransport(base http.RoundTripper) *CachingTransport {
+	if base == nil {
+		base = http.DefaultTransport
+	}
+
+	return &CachingTransport{
+		base:       base,
+		entries:    make(map[string]*list.Element),
+		recency:    list.New(),
+		MaxEntries: DEFAULT_CACHE_ENTRIES,
+	}
+}
+
+// Stats returns the hit/miss counts for the cache.
+func (transport *CachingTransport) Stats() CacheStats {
+	transport.mutex.Lock()
+	defer transport.mutex.Unlock()
+
+	return transport.stats
+}
+
+// RoundTrip implements `http.RoundTripper`.
+func (transport *CachingTransport) RoundTrip(req *http.Request) (*http.Response, error) {
+	if req.Method != http.MethodGet {
+		return transport.base.RoundTrip(req)
+	}
+
+	key := req.URL.String()
+	transport.mutex.Lock()
+	var entry cacheEntry
+	element, isCached := transport.entries[key]
+	if isCached {
+		entry = *element.Value.(*cacheEntry)
+		transport.recency.MoveToFront(element)
+	}
+	transport.mutex.Unlock()
+
+	if isCached {
+		// RoundTrippers must not modify the original request.
+		req = req.Clone(req.Context())
+		if entry.etag != "" {
+			req.Header.Set("If-None-Match", entry.etag)
+		}
+		if entry.lastModified != "" {
+			req.Header.Set("If-Modified-Since", entry.lastModified)
+		}
+	}
+
+	resp, err := transport.base.RoundTrip(req)
+	if err != nil {
+		return nil, err
+	}
+
+	if isCached && resp.StatusCode == http.StatusNotModified {
+		cached, err := http.ReadResponse(bufio.NewReader(bytes.NewReader(entry.response)), req)
+		if err != nil {
+			return resp, nil
+		}
+		resp.Body.Close()
+
+		// The headers of the fresh response are more accurate than the cached
+		// ones - i.e. the rate limit status - so they take precedence.
+		for header, values := range resp.Header {
+			if header != "Content-Length" {
+				cached.Header[header] = values
+			}
+		}
+
+		transport.mutex.Lock()
+		transport.stats.Hits++
+		transport.mutex.Unlock()
+		return cached, nil
+	}
+
+	transport.mutex.Lock()
+	transport.stats.Misses++
+	transport.mutex.Unlock()
+
+	etag, lastModified := resp.Header.Get("ETag"), resp.Header.Get("Last-Modified")
+	if resp.StatusCode != http.StatusOK || (etag == "" && lastModified == "") {
+		return resp, nil
+	}
+
+	// Read the entire response so it can be stored, and then replace the body
+	// so it's still available to the caller.
+	body, err := ioutil.ReadAll(resp.Body)
+	resp.Body.Close()
+	if err != nil {
+		return nil, err
+	}
+
+	resp.Body = ioutil.NopCloser(bytes.NewReader(body))
+	dump, err := httputil.DumpResponse(resp, true)
+	resp.Body = ioutil.NopCloser(bytes.NewReader(body))
+	if err != nil {
+		return resp, nil
+	}
+
+	transport.store(&cacheEntry{
+		key:          key,
+		etag:         etag,
+		lastModified: lastModified,
+		response:     dump,
+	})
+
+	return resp, nil
+}
+
+func (transport *CachingTransport) store(entry *cacheEntry) {
+	// Storing an entry counts as a request for it, and then the least recently
+	// requested entries are evicted until there's space.
+	transport.mutex.Lock()
+	defer transport.mutex.Unlock()
+
+	if element, isCached := transport.entries[entry.key]; isCached {
+		element.Value = entry
+		transport.recency.MoveToFront(element)
+	} else {
+		transport.entries[entry.key] = transport.recency.PushFront(entry)
+	}
+
+	for transport.MaxEntries > 0 && transport.recency.Len() > transport.MaxEntries {
+		oldest := transport.recency.Back()
+		transport.recency.Remove(oldest)
+		delete(transport.entries, oldest.Value.(*cacheEntry).key)
+	}
+}
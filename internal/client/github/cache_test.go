@@ -0,0 +1,101 @@
+package github
+
+import (
+	"io/ioutil"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+)
+
+// etagServer responds to every path with its own ETag, and with `304 Not
+// Modified` to any conditional request for the current ETag.
+func etagServer(t *testing.T) *httptest.Server {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		etag := `"` + r.URL.Path + `"`
+		w.Header().Set("ETag", etag)
+		if r.Header.Get("If-None-Match") == etag {
+			w.WriteHeader(http.StatusNotModified)
+			return
+		}
+
+		w.Write([]byte(r.URL.Path))
+	}))
+	t.Cleanup(server.Close)
+
+	return server
+}
+
+func TestCachingTransport(t *testing.T) {
+	tests := []struct {
+		name       string
+		maxEntries int
+		paths      []string
+		hits       []bool
+		cached     int
+	}{
+		{
+			name:       "repeated",
+			maxEntries: 2,
+			paths:      []string{"/a", "/a", "/b", "/a", "/b"},
+			hits:       []bool{false, true, false, true, true},
+			cached:     2,
+		},
+		{
+			name:       "least recently requested evicted",
+			maxEntries: 2,
+			paths:      []string{"/a", "/b", "/a", "/c", "/b", "/a"},
+			hits:       []bool{false, false, true, false, false, false},
+			cached:     2,
+		},
+		{
+			name:       "recently requested retained",
+			maxEntries: 2,
+			paths:      []string{"/a", "/b", "/a", "/c", "/a", "/c"},
+			hits:       []bool{false, false, true, false, true, true},
+			cached:     2,
+		},
+		{
+			name:       "unbounded",
+			maxEntries: 0,
+			paths:      []string{"/a", "/b", "/c", "/a", "/b", "/c"},
+			hits:       []bool{false, false, false, true, true, true},
+			cached:     3,
+		},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			server := etagServer(t)
+			transport := NewCachingTransport(nil)
+			transport.MaxEntries = test.maxEntries
+			client := &http.Client{Transport: transport}
+
+			for idx, path := range test.paths {
+				hits := transport.Stats().Hits
+				resp, err := client.Get(server.URL + path)
+				if err != nil {
+					t.Fatal(err)
+				}
+
+				body, err := ioutil.ReadAll(resp.Body)
+				resp.Body.Close()
+				if err != nil {
+					t.Fatal(err)
+				}
+
+				// A cached response is replayed in full.
+				if resp.StatusCode != http.StatusOK || string(body) != path {
+					t.Fatalf("request %d: expected the response for %s, found %s: %s", idx, path, resp.Status, body)
+				}
+
+				if hit := transport.Stats().Hits > hits; hit != test.hits[idx] {
+					t.Fatalf("request %d: expected %s to be a hit: %t, found %t", idx, path, test.hits[idx], hit)
+				}
+			}
+
+			if cached := transport.recency.Len(); cached != test.cached || len(transport.entries) != test.cached {
+				t.Fatalf("expected %d cached entries, found %d", test.cached, cached)
+			}
+		})
+	}
+}
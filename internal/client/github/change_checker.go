@@ -1,5 +1,7 @@
 package github
 
+import "sync"
+
 // ChangeCheckableCollection is an interface that a collection is expected
 // to implement if it's to be accepted by the ChangeChecker.
 type ChangeCheckableCollection interface {
@@ -9,26 +11,34 @@ type ChangeCheckableCollection interface {
 }
 
 // ChangeChecker is a simple mechanism for comparing two collections, and
-// determining whether or not any changes are present.
+// determining whether or not any changes are present. It retains the keys of
+// the most recently checked collection; so each call to `HasChanged` compares
+// against the previous call, rather than the collection it was created with.
+//
+// A ChangeChecker must be used via a pointer, and is safe for concurrent use.
 type ChangeChecker struct {
+	mutex         sync.Mutex
 	genKeyChecker keyCheckerGen
 	keyChecker    keyChecker
 }
 
-// A keychecker accepts a slice of strings (keys) and returns a boolean indicating
-// whether any changes have been observed. Whereas a keyCheckerGen is a function
-// that returns a keyChecker when provided a list of existing keys.
+// A keyChecker accepts a slice of strings (keys) and returns a boolean indicating
+// whether they match the keys it was generated from - i.e true when there are no
+// changes. Whereas a keyCheckerGen is a function that returns a keyChecker when
+// provided a list of existing keys.
 type keyCheckerGen func([]string) keyChecker
 type keyChecker func([]string) bool
 
 var (
 	// DEFAULT_KEY_CHECKER generates a map of keys, and checks against this map -
-	// as a result it is *not sensitive* to the ordering of a list of keys.
+	// as a result it is *not sensitive* to the ordering of a list of keys. Any
+	// duplicate keys are counted, so the two lists must contain the same number
+	// of each key.
 	DEFAULT_KEY_CHECKER = func(keyList []string) keyChecker {
 		// Simple closure to capture the state of the current batch of keys.
-		keyPresentMap := make(map[string]struct{})
+		keyCountMap := make(map[string]int)
 		for _, item := range keyList {
-			keyPresentMap[item] = struct{}{}
+			keyCountMap[item]++
 		}
 
 		return func(newKeylist []string) bool {
@@ -38,11 +48,18 @@ var (
 			}
 
 			// Check Two: Iterate through available keys - are they present in the
-			// key map we generated during initialisation?
+			// key map we generated during initialisation, and no more often?
+			remaining := make(map[string]int, len(keyCountMap))
+			for key, count := range keyCountMap {
+				remaining[key] = count
+			}
+
 			for _, key := range newKeylist {
-				if _, isPresent := keyPresentMap[key]; !isPresent {
+				if remaining[key] == 0 {
 					return false
 				}
+
+				remaining[key]--
 			}
 
 			return true
@@ -77,20 +94,32 @@ func NewChangeChecker(items ChangeCheckableCollection, gen keyCheckerGen) *Chang
 	}
 	return &ChangeChecker{
 		genKeyChecker: gen,
-		keyChecker:    gen(items.VersionKeys()),
+		keyChecker:    gen(copyKeys(items.VersionKeys())),
 	}
 }
 
 // HasChanged gets the key list for a new ChangeCheckableCollection, and then
 // runs the keyChecker against it. If a change is detected, then it generates
 // a new keyChecker for subsequent runs.
-func (cache ChangeChecker) HasChanged(items ChangeCheckableCollection) bool {
+func (cache *ChangeChecker) HasChanged(items ChangeCheckableCollection) bool {
 	keyList := items.VersionKeys()
+
+	cache.mutex.Lock()
+	defer cache.mutex.Unlock()
+
 	if cache.keyChecker(keyList) {
 		// Keys match
 		return false
 	}
 
-	cache.keyChecker = cache.genKeyChecker(keyList)
+	// The keyChecker retains the list it's generated from, so it mustn't share
+	// the slice with the collection.
+	cache.keyChecker = cache.genKeyChecker(copyKeys(keyList))
 	return true
 }
+
+func copyKeys(keys []string) []string {
+	copied := make([]string, len(keys))
+	copy(copied, keys)
+	return copied
+}
@@ -0,0 +1,155 @@
+package github
+
+import (
+	"sync"
+	"testing"
+)
+
+// keyCollection is a `ChangeCheckableCollection` of literal version keys.
+type keyCollection []string
+
+func (keys keyCollection) VersionKeys() []string {
+	return keys
+}
+
+func TestChangeCheckerSequences(t *testing.T) {
+	type step struct {
+		keys    keyCollection
+		changed bool
+	}
+
+	tests := []struct {
+		name    string
+		gen     keyCheckerGen
+		initial keyCollection
+		steps   []step
+	}{
+		{
+			name:    "default: unchanged",
+			gen:     DEFAULT_KEY_CHECKER,
+			initial: keyCollection{"a", "b"},
+			steps:   []step{{keyCollection{"a", "b"}, false}},
+		},
+		{
+			name:    "default: added",
+			gen:     DEFAULT_KEY_CHECKER,
+			initial: keyCollection{"a"},
+			steps:   []step{{keyCollection{"a", "b"}, true}, {keyCollection{"a", "b"}, false}},
+		},
+		{
+			name:    "default: removed",
+			gen:     DEFAULT_KEY_CHECKER,
+			initial: keyCollection{"a", "b"},
+			steps:   []step{{keyCollection{"a"}, true}, {keyCollection{"a"}, false}},
+		},
+		{
+			name:    "default: reordered",
+			gen:     DEFAULT_KEY_CHECKER,
+			initial: keyCollection{"a", "b", "c"},
+			steps:   []step{{keyCollection{"c", "a", "b"}, false}},
+		},
+		{
+			name:    "default: mutated",
+			gen:     DEFAULT_KEY_CHECKER,
+			initial: keyCollection{"a:open", "b:open"},
+			steps:   []step{{keyCollection{"a:open", "b:closed"}, true}, {keyCollection{"a:open", "b:closed"}, false}},
+		},
+		{
+			name:    "default: emptied",
+			gen:     DEFAULT_KEY_CHECKER,
+			initial: keyCollection{"a"},
+			steps:   []step{{keyCollection{}, true}, {keyCollection{}, false}, {keyCollection{"a"}, true}},
+		},
+		{
+			name:    "default: duplicates are counted",
+			gen:     DEFAULT_KEY_CHECKER,
+			initial: keyCollection{"a", "a", "b"},
+			steps: []step{
+				{keyCollection{"a", "b", "b"}, true},
+				{keyCollection{"b", "a", "b"}, false},
+				{keyCollection{"a", "a", "b"}, true},
+			},
+		},
+		{
+			name:    "simple: unchanged",
+			gen:     SIMPLE_KEY_CHECKER,
+			initial: keyCollection{"a", "b"},
+			steps:   []step{{keyCollection{"a", "b"}, false}},
+		},
+		{
+			name:    "simple: added",
+			gen:     SIMPLE_KEY_CHECKER,
+			initial: keyCollection{"a"},
+			steps:   []step{{keyCollection{"a", "b"}, true}, {keyCollection{"a", "b"}, false}},
+		},
+		{
+			name:    "simple: removed",
+			gen:     SIMPLE_KEY_CHECKER,
+			initial: keyCollection{"a", "b"},
+			steps:   []step{{keyCollection{"b"}, true}, {keyCollection{"b"}, false}},
+		},
+		{
+			name:    "simple: reordered",
+			gen:     SIMPLE_KEY_CHECKER,
+			initial: keyCollection{"a", "b", "c"},
+			steps:   []step{{keyCollection{"c", "a", "b"}, true}, {keyCollection{"c", "a", "b"}, false}},
+		},
+		{
+			name:    "simple: mutated",
+			gen:     SIMPLE_KEY_CHECKER,
+			initial: keyCollection{"a:open", "b:open"},
+			steps:   []step{{keyCollection{"a:open", "b:closed"}, true}, {keyCollection{"a:open", "b:closed"}, false}},
+		},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			checker := NewChangeChecker(test.initial, test.gen)
+			for idx, step := range test.steps {
+				if changed := checker.HasChanged(step.keys); changed != step.changed {
+					t.Fatalf("step %d: HasChanged(%v) = %t, expected %t", idx, step.keys, changed, step.changed)
+				}
+			}
+		})
+	}
+}
+
+func TestChangeCheckerRetainsUpdatedKeys(t *testing.T) {
+	// Regression: with a value receiver the updated keyChecker was discarded, so
+	// every subsequent call compared against the initial collection.
+	checker := NewChangeChecker(keyCollection{"a"}, nil)
+	if !checker.HasChanged(keyCollection{"b"}) {
+		t.Fatal("expected the first call to report a change")
+	}
+
+	if checker.HasChanged(keyCollection{"b"}) {
+		t.Fatal("expected the second call to report no change")
+	}
+}
+
+func TestChangeCheckerCopiesKeys(t *testing.T) {
+	// The keys retained by the checker mustn't share a slice with the collection.
+	keys := keyCollection{"a", "b"}
+	checker := NewChangeChecker(keys, SIMPLE_KEY_CHECKER)
+	keys[0] = "mutated"
+
+	if checker.HasChanged(keyCollection{"a", "b"}) {
+		t.Fatal("expected modifying the original slice not to affect the checker")
+	}
+}
+
+func TestChangeCheckerConcurrentUse(t *testing.T) {
+	checker := NewChangeChecker(keyCollection{}, nil)
+
+	var wg sync.WaitGroup
+	for worker := 0; worker < 8; worker++ {
+		wg.Add(1)
+		go func(worker int) {
+			defer wg.Done()
+			for idx := 0; idx < 100; idx++ {
+				checker.HasChanged(keyCollection{string(rune('a' + worker))})
+			}
+		}(worker)
+	}
+	wg.Wait()
+}
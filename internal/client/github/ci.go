@@ -0,0 +1,99 @@
+package github
+
+import (
+	"strings"
+
+	"github.com/google/go-github/v32/github"
+)
+
+const (
+	// CI_NONE indicates that no statuses or check runs are associated with the
+	// head commit of a Pull Request
+	CI_NONE = ""
+	// CI_PENDING indicates that at least one status or check run is incomplete
+	CI_PENDING = "pending"
+	// CI_SUCCESS indicates that all statuses and check runs have succeeded
+	CI_SUCCESS = "success"
+	// CI_FAILURE indicates that at least one status or check run has failed
+	CI_FAILURE = "failure"
+	// CI_ERROR indicates that at least one status or check run has errored
+	CI_ERROR = "error"
+)
+
+// ciStatePriority determines which state takes precedence when combining the
+// states of multiple statuses and check runs; the highest priority wins.
+var ciStatePriority = map[string]int{
+	CI_NONE:    0,
+	CI_SUCCESS: 1,
+	CI_PENDING: 2,
+	CI_FAILURE: 3,
+	CI_ERROR:   4,
+}
+
+// CombineCIStates reduces multiple normalised CI states into a single state; a
+// single error or failure is enough to mark the whole set as such.
+func CombineCIStates(states ...string) string {
+	combined := CI_NONE
+	for _, state := range states {
+		if ciStatePriority[state] > ciStatePriority[combined] {
+			combined = state
+		}
+	}
+
+	return combined
+}
+
+// NewCIStateFromAPI generates a normalised CI state from both the combined
+// commit status and the check runs associated with a commit; either may be nil.
+func NewCIStateFromAPI(status *github.CombinedStatus, checkRuns *github.ListCheckRunsResults) string {
+	states := make([]string, 0)
+
+	// The combined status is reported as "pending" when there are no statuses
+	// at all, so it's only considered when statuses are present.
+	if status != nil && status.GetTotalCount() > 0 {
+		states = append(states, normaliseCIState(status.GetState()))
+	}
+
+	if checkRuns != nil {
+		for _, checkRun := range checkRuns.CheckRuns {
+			states = append(states, checkRunState(checkRun.GetStatus(), checkRun.GetConclusion()))
+		}
+	}
+
+	return CombineCIStates(states...)
+}
+
+func checkRunState(status, conclusion string) string {
+	// Check runs are pending until completed; at which point their conclusion
+	// determines the outcome.
+	if !strings.EqualFold(status, "completed") {
+		return CI_PENDING
+	}
+
+	switch strings.ToLower(conclusion) {
+	case "success", "neutral", "skipped":
+		return CI_SUCCESS
+	case "failure", "timed_out", "action_required":
+		return CI_FAILURE
+	}
+
+	return CI_ERROR
+}
+
+func normaliseCIState(state string) string {
+	// Both the REST statuses and the GraphQL status rollup use the same values,
+	// albeit with different casing; GraphQL also has "expected", which indicates
+	// a status which is required but not yet reported.
+	switch strings.ToLower(state) {
+	case "success":
+		return CI_SUCCESS
+	case "pending", "expected":
+		return CI_PENDING
+	case "failure":
+		return CI_FAILURE
+	case "error":
+		return CI_ERROR
+	}
+
+	return CI_NONE
+}
@@ -0,0 +1,66 @@
+package github
+
+import (
+	"sync"
+	"time"
+)
+
+// fakeClock is a `Clock` which advances only when waited upon: each call to
+// `After` moves the time forward by the requested duration, and fires at once.
+// Callers relying upon timers - such as `Poller.Poll` - therefore run without
+// delay, and the durations they waited for can be inspected afterwards. A
+// paused clock never fires, nor advances, when waited upon.
+type fakeClock struct {
+	mutex  sync.Mutex
+	now    time.Time
+	waits  []time.Duration
+	paused bool
+}
+
+func newFakeClock() *fakeClock {
+	return &fakeClock{now: time.Date(2020, time.January, 1, 0, 0, 0, 0, time.UTC)}
+}
+
+func newPausedClock() *fakeClock {
+	clock := newFakeClock()
+	clock.paused = true
+	return clock
+}
+
+func (clock *fakeClock) Now() time.Time {
+	clock.mutex.Lock()
+	defer clock.mutex.Unlock()
+
+	return clock.now
+}
+
+func (clock *fakeClock) After(d time.Duration) <-chan time.Time {
+	clock.mutex.Lock()
+	defer clock.mutex.Unlock()
+
+	clock.waits = append(clock.waits, d)
+	if clock.paused {
+		return make(chan time.Time)
+	}
+
+	clock.now = clock.now.Add(d)
+	fired := make(chan time.Time, 1)
+	fired <- clock.now
+	return fired
+}
+
+// Advance moves the time forward without waiting upon it.
+func (clock *fakeClock) Advance(d time.Duration) {
+	clock.mutex.Lock()
+	defer clock.mutex.Unlock()
+
+	clock.now = clock.now.Add(d)
+}
+
+// Waits returns the duration of every call to `After`, in order.
+func (clock *fakeClock) Waits() []time.Duration {
+	clock.mutex.Lock()
+	defer clock.mutex.Unlock()
+
+	return append([]time.Duration(nil), clock.waits...)
+}
@@ -0,0 +1,114 @@
+package github
+
+import (
+	"context"
+	"errors"
+	"net/url"
+	"strconv"
+	"strings"
+	"time"
+)
+
+const (
+	// STATUS_OPEN is the status of an open Pull Request.
+	STATUS_OPEN = "open"
+	// STATUS_CLOSED is the status of a Pull Request closed without being merged.
+	STATUS_CLOSED = "closed"
+	// STATUS_MERGED is the status of a merged Pull Request.
+	STATUS_MERGED = "merged"
+
+	// DEFAULT_CLOSED_WINDOW is how long closed - and merged - Pull Requests are
+	// retained in the Poller's recently closed collection, unless configured.
+	DEFAULT_CLOSED_WINDOW = 24 * time.Hour
+)
+
+// resourceQuery retrieves the state of a single Pull Request via its URL.
+const resourceQuery = `
+query($url: URI!) {
+  resource(url: $url) { ... on PullRequest { state closedAt } }
+}`
+
+// ClosedResolver is implemented by any `PullRequestSource` which is able to
+// determine why a Pull Request is no longer amongst the open Pull Requests -
+// i.e whether it was merged, closed, or simply no longer assigned.
+type ClosedResolver interface {
+	// ResolveClosed returns the Pull Request with its `Status` - one of the
+	// `STATUS_` constants - and `ClosedAt` updated.
+	ResolveClosed(ctx context.Context, pr PullRequestSummary) (PullRequestSummary, error)
+}
+
+type graphQLResource struct {
+	Resource struct {
+		State    string    `json:"state"`
+		ClosedAt time.Time `json:"closedAt"`
+	} `json:"resource"`
+}
+
+// ResolveClosed retrieves the Pull Request via the Pull Requests API.
+func (source *RESTSource) ResolveClosed(ctx context.Context, pr PullRequestSummary) (PullRequestSummary, error) {
+	owner, repo, number, err := pullRequestLocation(pr.URL)
+	if err != nil {
+		return pr, err
+	}
+
+	details, resp, err := source.client.PullRequests.Get(ctx, owner, repo, number)
+	if err = source.observeREST(resp, err); err != nil {
+		return pr, err
+	}
+
+	pr.Status = details.GetState()
+	if details.GetMerged() {
+		pr.Status = STATUS_MERGED
+	}
+
+	pr.ClosedAt = details.GetClosedAt()
+	return pr, nil
+}
+
+// ResolveClosed retrieves the Pull Request via its URL; unlike the REST API,
+// the GraphQL API reports merged Pull Requests with a distinct state.
+func (source *GraphQLSource) ResolveClosed(ctx context.Context, pr PullRequestSummary) (PullRequestSummary, error) {
+	var result graphQLResource
+	if err := source.query(ctx, resourceQuery, map[string]interface{}{"url": pr.URL}, &result); err != nil {
+		return pr, err
+	}
+
+	if result.Resource.State == "" {
+		return pr, errors.New("graphql: pull request not found")
+	}
+
+	pr.Status = strings.ToLower(result.Resource.State)
+	pr.ClosedAt = result.Resource.ClosedAt
+	return pr, nil
+}
+
+// ResolveClosed resolves the Pull Request via the wrapped source, if possible.
+func (source *InstallationSource) ResolveClosed(ctx context.Context, pr PullRequestSummary) (PullRequestSummary, error) {
+	if resolver, isResolver := source.PullRequestSource.(ClosedResolver); isResolver {
+		return resolver.ResolveClosed(ctx, pr)
+	}
+
+	return pr, errors.New("source is unable to resolve closed pull requests")
+}
+
+// pullRequestLocation parses the owner, repository, and number from the URL of
+// a Pull Request - i.e https://github.com/[owner]/[repo]/pull/[number]; this is
+// equally applicable to Github Enterprise Server.
+func pullRequestLocation(pullRequestURL string) (string, string, int, error) {
+	parsed, err := url.Parse(pullRequestURL)
+	if err != nil {
+		return "", "", 0, err
+	}
+
+	segments := strings.Split(strings.Trim(parsed.Path, "/"), "/")
+	if len(segments) < 4 || segments[len(segments)-2] != "pull" {
+		return "", "", 0, errors.New("invalid pull request url: " + pullRequestURL)
+	}
+
+	number, err := strconv.Atoi(segments[len(segments)-1])
+	if err != nil {
+		return "", "", 0, errors.New("invalid pull request url: " + pullRequestURL)
+	}
+
+	return segments[len(segments)-4], segments[len(segments)-3], number, nil
+}
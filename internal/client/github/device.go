@@ -0,0 +1,181 @@
+package github
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"net/url"
+	"strings"
+	"time"
+)
+
+const (
+	// DEVICE_CODE_PATH is the path - relative to the web URL - used to request
+	// a device and user code.
+	DEVICE_CODE_PATH = "login/device/code"
+	// DEVICE_TOKEN_PATH is the path - relative to the web URL - polled for the
+	// access token.
+	DEVICE_TOKEN_PATH = "login/oauth/access_token"
+	// DEVICE_GRANT_TYPE is the grant type used when polling for the access token.
+	DEVICE_GRANT_TYPE = "urn:ietf:params:oauth:grant-type:device_code"
+	// DEVICE_SLOW_DOWN_INTERVAL is added to the polling interval whenever Github
+	// responds with `slow_down`.
+	DEVICE_SLOW_DOWN_INTERVAL = 5 * time.Second
+)
+
+// DeviceCode is returned by Github at the start of the device authorization
+// flow; the user must enter the `UserCode` at the `VerificationURI`.
+type DeviceCode struct {
+	DeviceCode      string `json:"device_code"`
+	UserCode        string `json:"user_code"`
+	VerificationURI string `json:"verification_uri"`
+	ExpiresIn       int    `json:"expires_in"`
+	Interval        int    `json:"interval"`
+}
+
+// deviceResponse contains the fields of every response from the device flow
+// endpoints; errors are reported via the `error` field, rather than the status.
+type deviceResponse struct {
+	DeviceCode
+	AccessToken      string `json:"access_token"`
+	Scope            string `json:"scope"`
+	Error            string `json:"error"`
+	ErrorDescription string `json:"error_description"`
+}
+
+// DeviceFlow implements Github's OAuth device authorization flow; allowing a
+// token to be generated - with the correct scopes - via the browser, rather
+// than the user creating a personal access token themselves.
+type DeviceFlow struct {
+	client   *http.Client
+	clock    Clock
+	webURL   string
+	clientID string
+	scopes   []string
+}
+
+// NewDeviceFlow returns a `DeviceFlow` for the OAuth App identified by `clientID`,
+// requesting the provided scopes. If an `http.Client` isn't provided then the
+// default client is used; likewise for the `Clock`.
+func NewDeviceFlow(endpoints Endpoints, clientID string, scopes []string, client *http.Client, clock Clock) *DeviceFlow {
+	if client == nil {
+		client = http.DefaultClient
+	}
+
+	if clock == nil {
+		clock = realClock{}
+	}
+
+	webURL := endpoints.WebURL
+	if webURL == "" {
+		webURL = DEFAULT_WEB_URL
+	}
+
+	return &DeviceFlow{
+		client:   client,
+		clock:    clock,
+		webURL:   webURL,
+		clientID: clientID,
+		scopes:   scopes,
+	}
+}
+
+// RequestCode starts the flow, returning the code which the user must enter.
+func (flow *DeviceFlow) RequestCode(ctx context.Context) (*DeviceCode, error) {
+	resp, err := flow.post(ctx, DEVICE_CODE_PATH, url.Values{
+		"client_id": {flow.clientID},
+		"scope":     {strings.Join(flow.scopes, " ")},
+	})
+	if err != nil {
+		return nil, fmt.Errorf("unable to request device code: %w", err)
+	}
+
+	return &resp.DeviceCode, nil
+}
+
+// PollToken polls Github - at the interval it requested - until the user has
+// entered the code, returning the access token. An error is returned if the
+// user denies access, or the code expires.
+func (flow *DeviceFlow) PollToken(ctx context.Context, code *DeviceCode) (string, error) {
+	interval := time.Duration(code.Interval) * time.Second
+	expiry := flow.clock.Now().Add(time.Duration(code.ExpiresIn) * time.Second)
+
+	for {
+		select {
+		case <-ctx.Done():
+			return "", ctx.Err()
+		case <-flow.clock.After(interval):
+			// Github would only respond with `expired_token` at this point.
+			if !flow.clock.Now().Before(expiry) {
+				return "", errors.New("device code expired before access was granted")
+			}
+
+			resp, err := flow.post(ctx, DEVICE_TOKEN_PATH, url.Values{
+				"client_id":   {flow.clientID},
+				"device_code": {code.DeviceCode},
+				"grant_type":  {DEVICE_GRANT_TYPE},
+			})
+			if err != nil {
+				return "", fmt.Errorf("unable to retrieve access token: %w", err)
+			}
+
+			switch resp.Error {
+			case "":
+				return resp.AccessToken, nil
+			case "authorization_pending":
+				continue
+			case "slow_down":
+				interval += DEVICE_SLOW_DOWN_INTERVAL
+				if resp.Interval > 0 {
+					interval = time.Duration(resp.Interval) * time.Second
+				}
+				continue
+			}
+
+			return "", fmt.Errorf("unable to retrieve access token: %s", deviceError(resp))
+		}
+	}
+}
+
+func (flow *DeviceFlow) post(ctx context.Context, path string, form url.Values) (*deviceResponse, error) {
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, flow.webURL+path, strings.NewReader(form.Encode()))
+	if err != nil {
+		return nil, err
+	}
+
+	req.Header.Set("Content-Type", "application/x-www-form-urlencoded")
+	req.Header.Set("Accept", "application/json")
+
+	resp, err := flow.client.Do(req)
+	if err != nil {
+		return nil, err
+	}
+	defer resp.Body.Close()
+
+	var decoded deviceResponse
+	if err := json.NewDecoder(resp.Body).Decode(&decoded); err != nil {
+		if resp.StatusCode != http.StatusOK {
+			return nil, errors.New(resp.Status)
+		}
+
+		return nil, err
+	}
+
+	// Only the token endpoint's errors - i.e `authorization_pending` - are
+	// handled by the caller; anything else is a failure.
+	if decoded.Error != "" && path == DEVICE_CODE_PATH {
+		return nil, errors.New(deviceError(&decoded))
+	}
+
+	return &decoded, nil
+}
+
+func deviceError(resp *deviceResponse) string {
+	if resp.ErrorDescription != "" {
+		return fmt.Sprintf("%s (%s)", resp.ErrorDescription, resp.Error)
+	}
+
+	return resp.Error
+}
@@ -0,0 +1,189 @@
+package github
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"reflect"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+)
+
+// newTestDeviceFlow returns a `DeviceFlow` whose token requests receive each of
+// the `responses` in turn - the final response is repeated - along with the
+// number of token requests made.
+func newTestDeviceFlow(t *testing.T, clock Clock, responses ...map[string]interface{}) (*DeviceFlow, func() int) {
+	t.Helper()
+
+	var mutex sync.Mutex
+	requests := 0
+
+	mux := http.NewServeMux()
+	mux.HandleFunc("/"+DEVICE_CODE_PATH, func(w http.ResponseWriter, r *http.Request) {
+		if r.FormValue("client_id") != "client" || r.FormValue("scope") != "repo read:org" {
+			writeJSON(t, w, map[string]interface{}{"error": "invalid_request", "error_description": "Unexpected parameters"})
+			return
+		}
+
+		writeJSON(t, w, map[string]interface{}{
+			"device_code":      "device",
+			"user_code":        "ABCD-1234",
+			"verification_uri": "https://github.com/login/device",
+			"expires_in":       900,
+			"interval":         5,
+		})
+	})
+
+	mux.HandleFunc("/"+DEVICE_TOKEN_PATH, func(w http.ResponseWriter, r *http.Request) {
+		if r.FormValue("device_code") != "device" || r.FormValue("grant_type") != DEVICE_GRANT_TYPE {
+			t.Errorf("unexpected token request %v", r.Form)
+		}
+
+		mutex.Lock()
+		response := responses[len(responses)-1]
+		if requests < len(responses) {
+			response = responses[requests]
+		}
+		requests++
+		mutex.Unlock()
+
+		writeJSON(t, w, response)
+	})
+
+	server := httptest.NewServer(mux)
+	t.Cleanup(server.Close)
+
+	flow := NewDeviceFlow(Endpoints{WebURL: server.URL + "/"}, "client", []string{"repo", "read:org"}, server.Client(), clock)
+	return flow, func() int {
+		mutex.Lock()
+		defer mutex.Unlock()
+
+		return requests
+	}
+}
+
+func TestDeviceFlowRequestCode(t *testing.T) {
+	flow, _ := newTestDeviceFlow(t, nil)
+	code, err := flow.RequestCode(context.Background())
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	expected := DeviceCode{DeviceCode: "device", UserCode: "ABCD-1234", VerificationURI: "https://github.com/login/device", ExpiresIn: 900, Interval: 5}
+	if *code != expected {
+		t.Fatalf("expected the code %+v, found %+v", expected, *code)
+	}
+
+	flow.clientID = "unknown"
+	if _, err := flow.RequestCode(context.Background()); err == nil || !strings.Contains(err.Error(), "invalid_request") {
+		t.Fatalf("expected the error to be reported, found %v", err)
+	}
+}
+
+func TestDeviceFlowPollToken(t *testing.T) {
+	pending := map[string]interface{}{"error": "authorization_pending"}
+	slowDown := map[string]interface{}{"error": "slow_down"}
+	granted := map[string]interface{}{"access_token": "token", "scope": "repo,read:org"}
+
+	tests := []struct {
+		name      string
+		expiresIn int
+		responses []map[string]interface{}
+		token     string
+		err       string
+		// Intervals waited between each token request
+		waits []time.Duration
+	}{
+		{
+			name:      "granted",
+			responses: []map[string]interface{}{granted},
+			token:     "token",
+			waits:     []time.Duration{5 * time.Second},
+		},
+		{
+			name:      "authorization pending",
+			responses: []map[string]interface{}{pending, pending, granted},
+			token:     "token",
+			waits:     []time.Duration{5 * time.Second, 5 * time.Second, 5 * time.Second},
+		},
+		{
+			name:      "slow down",
+			responses: []map[string]interface{}{slowDown, pending, slowDown, granted},
+			token:     "token",
+			waits:     []time.Duration{5 * time.Second, 10 * time.Second, 10 * time.Second, 15 * time.Second},
+		},
+		{
+			name:      "slow down to the requested interval",
+			responses: []map[string]interface{}{{"error": "slow_down", "interval": 30}, granted},
+			token:     "token",
+			waits:     []time.Duration{5 * time.Second, 30 * time.Second},
+		},
+		{
+			name:      "expired token",
+			responses: []map[string]interface{}{pending, {"error": "expired_token", "error_description": "The device code has expired"}},
+			err:       "The device code has expired (expired_token)",
+			waits:     []time.Duration{5 * time.Second, 5 * time.Second},
+		},
+		{
+			name:      "access denied",
+			responses: []map[string]interface{}{{"error": "access_denied"}},
+			err:       "access_denied",
+			waits:     []time.Duration{5 * time.Second},
+		},
+		{
+			name:      "expired before access is granted",
+			expiresIn: 20,
+			responses: []map[string]interface{}{pending},
+			err:       "device code expired",
+			waits:     []time.Duration{5 * time.Second, 5 * time.Second, 5 * time.Second, 5 * time.Second},
+		},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			clock := newFakeClock()
+			flow, requests := newTestDeviceFlow(t, clock, test.responses...)
+
+			expiresIn := test.expiresIn
+			if expiresIn == 0 {
+				expiresIn = 900
+			}
+
+			token, err := flow.PollToken(context.Background(), &DeviceCode{DeviceCode: "device", ExpiresIn: expiresIn, Interval: 5})
+			if test.err != "" {
+				if err == nil || !strings.Contains(err.Error(), test.err) {
+					t.Fatalf("expected the error '%s', found %v", test.err, err)
+				}
+			} else if err != nil || token != test.token {
+				t.Fatalf("expected the token '%s', found '%s' (%v)", test.token, token, err)
+			}
+
+			if waits := clock.Waits(); !reflect.DeepEqual(waits, test.waits) {
+				t.Errorf("expected to wait %v, waited %v", test.waits, waits)
+			}
+
+			// Once the code has expired, Github isn't polled again.
+			expected := len(test.waits)
+			if test.expiresIn > 0 {
+				expected--
+			}
+
+			if requests() != expected {
+				t.Errorf("expected %d token requests, found %d", expected, requests())
+			}
+		})
+	}
+}
+
+func TestDeviceFlowPollTokenCancelled(t *testing.T) {
+	flow, _ := newTestDeviceFlow(t, nil, map[string]interface{}{"error": "authorization_pending"})
+
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+
+	if _, err := flow.PollToken(ctx, &DeviceCode{DeviceCode: "device", ExpiresIn: 900, Interval: 5}); err != context.Canceled {
+		t.Fatalf("expected the context's error, found %v", err)
+	}
+}
@@ -0,0 +1,158 @@
+package github
+
+import (
+	"sort"
+	"strconv"
+)
+
+const (
+	// FIELD_DRAFT indicates a Pull Request has been marked as - or is no longer -
+	// a draft
+	FIELD_DRAFT = "draft"
+	// FIELD_STATUS indicates the state of a Pull Request - i.e open - has changed
+	FIELD_STATUS = "status"
+	// FIELD_REVIEWERS indicates the number of reviewers has changed
+	FIELD_REVIEWERS = "reviewers"
+	// FIELD_REVIEW indicates a reviewer has submitted a new review; the reviewer
+	// is the `Subject` of the change
+	FIELD_REVIEW = "review"
+	// FIELD_DECISION indicates the overall review decision has changed
+	FIELD_DECISION = "decision"
+	// FIELD_CI indicates the CI state of the head commit has changed
+	FIELD_CI = "ci"
+	// FIELD_COMMENTS indicates the number of comments has changed
+	FIELD_COMMENTS = "comments"
+)
+
+// Diff describes the changes between two sets of Pull Requests; Pull Requests
+// are matched via their `Key`.
+type Diff struct {
+	// Pull Requests which weren't present in the previous set
+	Added []PullRequestSummary
+	// Pull Requests which aren't present in the current set
+	Removed []PullRequestSummary
+	// Pull Requests present in both sets, but with differing fields
+	Modified []PullRequestChange
+}
+
+// PullRequestChange describes the changes to a single Pull Request.
+type PullRequestChange struct {
+	Previous PullRequestSummary
+	Current  PullRequestSummary
+	Fields   []FieldChange
+}
+
+// FieldChange describes the change to a single field of a Pull Request; values
+// are formatted as strings, so booleans are either "true" or "false".
+type FieldChange struct {
+	// One of the `FIELD_` constants
+	Field string
+	// What the change applies to, if the field has multiple values - i.e the
+	// reviewer login for `FIELD_REVIEW`
+	Subject  string
+	Previous string
+	Current  string
+}
+
+// PollChanges contains the Diff of each collection from a single poll; it's
+// included in the `Notification` whenever any of the collections change.
+type PollChanges struct {
+	Assigned        Diff
+	Created         Diff
+	ReviewRequested Diff
+	// Diffs of the saved searches, in the same order as the searches
+	Searches []Diff
+	// Diff of the recently closed Pull Requests
+	RecentlyClosed Diff
+}
+
+// NewDiff compares two sets of Pull Requests; the order of either set doesn't
+// matter, and only the fields included in the version key are compared.
+func NewDiff(previous, current []PullRequestSummary) Diff {
+	diff := Diff{}
+	previousByKey := make(map[string]PullRequestSummary, len(previous))
+	for _, pr := range previous {
+		previousByKey[pr.Key()] = pr
+	}
+
+	currentKeys := make(map[string]struct{}, len(current))
+	for _, pr := range current {
+		currentKeys[pr.Key()] = struct{}{}
+
+		previousPR, isPresent := previousByKey[pr.Key()]
+		if !isPresent {
+			diff.Added = append(diff.Added, pr)
+			continue
+		}
+
+		if fields := fieldChanges(previousPR, pr); len(fields) > 0 {
+			diff.Modified = append(diff.Modified, PullRequestChange{
+				Previous: previousPR,
+				Current:  pr,
+				Fields:   fields,
+			})
+		}
+	}
+
+	for _, pr := range previous {
+		if _, isPresent := currentKeys[pr.Key()]; !isPresent {
+			diff.Removed = append(diff.Removed, pr)
+		}
+	}
+
+	return diff
+}
+
+// Empty returns whether the Diff contains no changes.
+func (diff Diff) Empty() bool {
+	return len(diff.Added) == 0 && len(diff.Removed) == 0 && len(diff.Modified) == 0
+}
+
+// Empty returns whether none of the collections have changed.
+func (changes *PollChanges) Empty() bool {
+	for _, diff := range changes.Searches {
+		if !diff.Empty() {
+			return false
+		}
+	}
+
+	return changes.Assigned.Empty() && changes.Created.Empty() && changes.ReviewRequested.Empty() &&
+		changes.RecentlyClosed.Empty()
+}
+
+func fieldChanges(previous, current PullRequestSummary) []FieldChange {
+	fields := make([]FieldChange, 0)
+	compare := func(field, subject, previous, current string) {
+		if previous != current {
+			fields = append(fields, FieldChange{Field: field, Subject: subject, Previous: previous, Current: current})
+		}
+	}
+
+	compare(FIELD_DRAFT, "", strconv.FormatBool(previous.Draft), strconv.FormatBool(current.Draft))
+	compare(FIELD_STATUS, "", previous.Status, current.Status)
+	compare(FIELD_REVIEWERS, "", strconv.Itoa(previous.ReviewerCount), strconv.Itoa(current.ReviewerCount))
+	compare(FIELD_DECISION, "", previous.Review.Decision, current.Review.Decision)
+
+	// Reviews are compared per reviewer - in a stable order - so that a new
+	// review is reported even if it doesn't alter the overall decision; as are
+	// the reviews which have been dismissed, and are no longer present.
+	reviewers := make([]string, 0, len(current.Review.Reviews))
+	for reviewer := range current.Review.Reviews {
+		reviewers = append(reviewers, reviewer)
+	}
+
+	for reviewer := range previous.Review.Reviews {
+		if _, isPresent := current.Review.Reviews[reviewer]; !isPresent {
+			reviewers = append(reviewers, reviewer)
+		}
+	}
+	sort.Strings(reviewers)
+
+	for _, reviewer := range reviewers {
+		compare(FIELD_REVIEW, reviewer, previous.Review.Reviews[reviewer], current.Review.Reviews[reviewer])
+	}
+
+	compare(FIELD_CI, "", previous.CIState, current.CIState)
+	compare(FIELD_COMMENTS, "", strconv.Itoa(previous.Comments), strconv.Itoa(current.Comments))
+	return fields
+}
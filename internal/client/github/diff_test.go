@@ -0,0 +1,182 @@
+package github
+
+import (
+	"reflect"
+	"testing"
+)
+
+func TestNewDiff(t *testing.T) {
+	a, b, c := fakePullRequest(1), fakePullRequest(2), fakePullRequest(3)
+	modifiedB := b
+	modifiedB.Comments = 2
+
+	tests := []struct {
+		name     string
+		previous []PullRequestSummary
+		current  []PullRequestSummary
+		added    []string
+		removed  []string
+		modified []string
+	}{
+		{name: "empty", previous: nil, current: nil},
+		{name: "unchanged", previous: []PullRequestSummary{a, b}, current: []PullRequestSummary{a, b}},
+		{name: "reordered", previous: []PullRequestSummary{a, b}, current: []PullRequestSummary{b, a}},
+		{name: "added", previous: []PullRequestSummary{a}, current: []PullRequestSummary{a, b}, added: []string{b.Key()}},
+		{name: "removed", previous: []PullRequestSummary{a, b}, current: []PullRequestSummary{b}, removed: []string{a.Key()}},
+		{name: "modified", previous: []PullRequestSummary{a, b}, current: []PullRequestSummary{a, modifiedB}, modified: []string{b.Key()}},
+		{
+			name:     "added, removed and modified",
+			previous: []PullRequestSummary{a, b},
+			current:  []PullRequestSummary{modifiedB, c},
+			added:    []string{c.Key()},
+			removed:  []string{a.Key()},
+			modified: []string{b.Key()},
+		},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			diff := NewDiff(test.previous, test.current)
+
+			modified := make([]PullRequestSummary, len(diff.Modified))
+			for idx, change := range diff.Modified {
+				modified[idx] = change.Current
+			}
+
+			for _, collection := range []struct {
+				name     string
+				received []PullRequestSummary
+				expected []string
+			}{
+				{"added", diff.Added, test.added},
+				{"removed", diff.Removed, test.removed},
+				{"modified", modified, test.modified},
+			} {
+				if received := keys(collection.received); len(received) != len(collection.expected) ||
+					(len(received) > 0 && !reflect.DeepEqual(received, collection.expected)) {
+					t.Errorf("expected %v to be %s, found %v", collection.expected, collection.name, received)
+				}
+			}
+
+			if isEmpty := len(test.added)+len(test.removed)+len(test.modified) == 0; diff.Empty() != isEmpty {
+				t.Errorf("expected Empty to be %t", isEmpty)
+			}
+		})
+	}
+}
+
+func TestNewDiffFieldChanges(t *testing.T) {
+	base := fakePullRequest(1)
+	base.ReviewerCount = 1
+	base.Review = ReviewSummary{Decision: REVIEW_PENDING, Reviews: map[string]string{"alice": REVIEW_COMMENTED}}
+	base.CIState = CI_PENDING
+	base.Comments = 1
+
+	tests := []struct {
+		name   string
+		modify func(pr *PullRequestSummary)
+		fields []FieldChange
+	}{
+		{
+			name:   "draft",
+			modify: func(pr *PullRequestSummary) { pr.Draft = true },
+			fields: []FieldChange{{Field: FIELD_DRAFT, Previous: "false", Current: "true"}},
+		},
+		{
+			name:   "status",
+			modify: func(pr *PullRequestSummary) { pr.Status = STATUS_MERGED },
+			fields: []FieldChange{{Field: FIELD_STATUS, Previous: STATUS_OPEN, Current: STATUS_MERGED}},
+		},
+		{
+			name:   "reviewers",
+			modify: func(pr *PullRequestSummary) { pr.ReviewerCount = 3 },
+			fields: []FieldChange{{Field: FIELD_REVIEWERS, Previous: "1", Current: "3"}},
+		},
+		{
+			name:   "decision",
+			modify: func(pr *PullRequestSummary) { pr.Review.Decision = REVIEW_APPROVED },
+			fields: []FieldChange{{Field: FIELD_DECISION, Previous: REVIEW_PENDING, Current: REVIEW_APPROVED}},
+		},
+		{
+			name: "review submitted",
+			modify: func(pr *PullRequestSummary) {
+				pr.Review.Reviews = map[string]string{"alice": REVIEW_COMMENTED, "bob": REVIEW_APPROVED}
+			},
+			fields: []FieldChange{{Field: FIELD_REVIEW, Subject: "bob", Previous: "", Current: REVIEW_APPROVED}},
+		},
+		{
+			name:   "review updated",
+			modify: func(pr *PullRequestSummary) { pr.Review.Reviews = map[string]string{"alice": REVIEW_CHANGES_REQUESTED} },
+			fields: []FieldChange{{Field: FIELD_REVIEW, Subject: "alice", Previous: REVIEW_COMMENTED, Current: REVIEW_CHANGES_REQUESTED}},
+		},
+		{
+			name:   "review dismissed",
+			modify: func(pr *PullRequestSummary) { pr.Review.Reviews = nil },
+			fields: []FieldChange{{Field: FIELD_REVIEW, Subject: "alice", Previous: REVIEW_COMMENTED, Current: ""}},
+		},
+		{
+			name:   "reviews replaced",
+			modify: func(pr *PullRequestSummary) { pr.Review.Reviews = map[string]string{"carol": REVIEW_APPROVED} },
+			fields: []FieldChange{
+				{Field: FIELD_REVIEW, Subject: "alice", Previous: REVIEW_COMMENTED, Current: ""},
+				{Field: FIELD_REVIEW, Subject: "carol", Previous: "", Current: REVIEW_APPROVED},
+			},
+		},
+		{
+			name:   "ci",
+			modify: func(pr *PullRequestSummary) { pr.CIState = CI_FAILURE },
+			fields: []FieldChange{{Field: FIELD_CI, Previous: CI_PENDING, Current: CI_FAILURE}},
+		},
+		{
+			name:   "comments",
+			modify: func(pr *PullRequestSummary) { pr.Comments = 4 },
+			fields: []FieldChange{{Field: FIELD_COMMENTS, Previous: "1", Current: "4"}},
+		},
+		{
+			name: "multiple fields",
+			modify: func(pr *PullRequestSummary) {
+				pr.Draft = true
+				pr.CIState = CI_SUCCESS
+			},
+			fields: []FieldChange{
+				{Field: FIELD_DRAFT, Previous: "false", Current: "true"},
+				{Field: FIELD_CI, Previous: CI_PENDING, Current: CI_SUCCESS},
+			},
+		},
+		{
+			name:   "unversioned field",
+			modify: func(pr *PullRequestSummary) { pr.Title = "Renamed" },
+		},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			current := base
+			current.Review.Reviews = make(map[string]string)
+			for reviewer, state := range base.Review.Reviews {
+				current.Review.Reviews[reviewer] = state
+			}
+			test.modify(&current)
+
+			diff := NewDiff([]PullRequestSummary{base}, []PullRequestSummary{current})
+			if len(diff.Added) > 0 || len(diff.Removed) > 0 {
+				t.Fatalf("expected the Pull Request to be matched, found %+v", diff)
+			}
+
+			if len(test.fields) == 0 {
+				if len(diff.Modified) > 0 {
+					t.Fatalf("expected no changes, found %+v", diff.Modified)
+				}
+				return
+			}
+
+			if len(diff.Modified) != 1 {
+				t.Fatalf("expected a single modified Pull Request, found %d", len(diff.Modified))
+			}
+
+			if fields := diff.Modified[0].Fields; !reflect.DeepEqual(fields, test.fields) {
+				t.Errorf("expected the changes %+v, found %+v", test.fields, fields)
+			}
+		})
+	}
+}
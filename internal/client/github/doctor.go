@@ -0,0 +1,252 @@
+package github
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"strings"
+	"time"
+
+	"github.com/google/go-github/v32/github"
+	"golang.org/x/oauth2"
+)
+
+const (
+	// CHECK_PASS indicates a check which found no problems.
+	CHECK_PASS = "pass"
+	// CHECK_WARN indicates a check which found something that may cause Pull
+	// Requests to be missing, but isn't necessarily a problem.
+	CHECK_WARN = "warn"
+	// CHECK_FAIL indicates a check which found a problem.
+	CHECK_FAIL = "fail"
+
+	// SSO_HEADER is the header in which Github reports that SAML SSO authorization
+	// is required to access an organisation's resources.
+	SSO_HEADER = "X-GitHub-SSO"
+	// MAX_CLOCK_SKEW is the largest difference permitted between the local clock
+	// and Github's, before it's reported as a failure.
+	MAX_CLOCK_SKEW = 30 * time.Second
+	// RATE_LIMIT_WARNING_RATIO is the proportion of the rate limit remaining
+	// below which a warning is reported.
+	RATE_LIMIT_WARNING_RATIO = 0.1
+	// DOCTOR_ORGS_PAGE_SIZE is the maximum number of organisations listed.
+	DOCTOR_ORGS_PAGE_SIZE = 100
+)
+
+// CheckResult is the outcome of a single diagnostic check; `Advice` describes
+// how to resolve any warning or failure.
+type CheckResult struct {
+	Name   string
+	Status string
+	Detail string
+	Advice string
+}
+
+// Doctor diagnoses why Pull Requests may be missing - i.e the token lacking the
+// required scopes, or SSO authorization for an organisation - by inspecting the
+// responses to a handful of requests made via the REST API.
+type Doctor struct {
+	source    *RESTSource
+	endpoints Endpoints
+	clock     Clock
+}
+
+// NewDoctor returns a `Doctor` for the token and Github instance; if a `Clock`
+// is not explicitly provided, then the system clock will be used.
+func NewDoctor(ctx context.Context, endpoints Endpoints, token string, clock Clock) (*Doctor, error) {
+	if clock == nil {
+		clock = realClock{}
+	}
+
+	source, err := newRESTSource(ctx, endpoints, oauth2.StaticTokenSource(&oauth2.Token{
+		AccessToken: token,
+	}))
+	if err != nil {
+		return nil, err
+	}
+
+	return &Doctor{source: source, endpoints: endpoints, clock: clock}, nil
+}
+
+// Run executes every check, in order; if the host can't be reached, or the token
+// isn't valid, then the subsequent checks are skipped.
+func (doctor *Doctor) Run(ctx context.Context) []CheckResult {
+	if err := CheckHost(ctx, doctor.endpoints); err != nil {
+		return []CheckResult{{
+			Name:   "host",
+			Status: CHECK_FAIL,
+			Detail: err.Error(),
+			Advice: "check the configured host and base url, and any proxy or vpn required to reach it",
+		}}
+	}
+
+	results := []CheckResult{{Name: "host", Status: CHECK_PASS, Detail: doctor.endpoints.BaseURL}}
+
+	user, resp, err := doctor.source.client.Users.Get(ctx, "")
+	if err = doctor.source.observeREST(resp, err); err != nil {
+		return append(results, CheckResult{
+			Name:   "authentication",
+			Status: CHECK_FAIL,
+			Detail: err.Error(),
+			Advice: "the token may have expired or been revoked; generate a new token, or use the login command",
+		})
+	}
+
+	results = append(results, CheckResult{Name: "authentication", Status: CHECK_PASS, Detail: "authenticated as " + user.GetLogin()})
+
+	// Fine-grained tokens don't have scopes, so the header is absent entirely;
+	// this is distinct from a token without any scopes.
+	var scopes []string
+	if _, isPresent := resp.Header[http.CanonicalHeaderKey(SCOPES_HEADER)]; isPresent {
+		scopes = ParseScopes(resp.Header.Get(SCOPES_HEADER))
+	}
+
+	return append(results,
+		doctor.checkScopes(scopes),
+		doctor.checkClockSkew(resp),
+		doctor.checkRateLimit(ctx),
+		doctor.checkOrganisations(ctx),
+		doctor.checkPrivateRepositories(ctx, scopes),
+	)
+}
+
+func (doctor *Doctor) checkScopes(scopes []string) CheckResult {
+	if scopes == nil {
+		return CheckResult{
+			Name:   "scopes",
+			Status: CHECK_WARN,
+			Detail: "the token doesn't report any scopes; it may be a fine-grained token",
+			Advice: "ensure the token has read access to pull requests, issues and metadata for every repository",
+		}
+	}
+
+	if missing := MissingScopes(scopes, REQUIRED_SCOPES); len(missing) > 0 {
+		return CheckResult{
+			Name:   "scopes",
+			Status: CHECK_FAIL,
+			Detail: fmt.Sprintf("granted '%s', missing '%s'", strings.Join(scopes, ", "), strings.Join(missing, ", ")),
+			Advice: fmt.Sprintf("generate a token with the %s scopes", strings.Join(REQUIRED_SCOPES, " and ")),
+		}
+	}
+
+	return CheckResult{Name: "scopes", Status: CHECK_PASS, Detail: "granted " + strings.Join(scopes, ", ")}
+}
+
+func (doctor *Doctor) checkClockSkew(resp *github.Response) CheckResult {
+	// The skew includes the latency of the request, so it's only approximate.
+	date, err := http.ParseTime(resp.Header.Get("Date"))
+	if err != nil {
+		return CheckResult{Name: "clock", Status: CHECK_WARN, Detail: "github didn't report the current time"}
+	}
+
+	skew := doctor.clock.Now().Sub(date)
+	if skew < 0 {
+		skew = -skew
+	}
+
+	if skew > MAX_CLOCK_SKEW {
+		return CheckResult{
+			Name:   "clock",
+			Status: CHECK_FAIL,
+			Detail: fmt.Sprintf("local clock differs from github's by %s", skew.Round(time.Second)),
+			Advice: "synchronise the local clock - i.e via ntp; rate limit resets and github app tokens rely upon it",
+		}
+	}
+
+	return CheckResult{Name: "clock", Status: CHECK_PASS, Detail: fmt.Sprintf("within %s of github's", MAX_CLOCK_SKEW)}
+}
+
+func (doctor *Doctor) checkRateLimit(ctx context.Context) CheckResult {
+	limits, resp, err := doctor.source.client.RateLimits(ctx)
+	if err = doctor.source.observeREST(resp, err); err != nil {
+		return CheckResult{Name: "rate limit", Status: CHECK_WARN, Detail: err.Error()}
+	}
+
+	core := limits.GetCore()
+	detail := fmt.Sprintf("%d/%d requests remaining, resets at %s", core.Remaining, core.Limit, core.Reset.Format(time.Kitchen))
+	switch {
+	case core.Remaining == 0:
+		return CheckResult{
+			Name:   "rate limit",
+			Status: CHECK_FAIL,
+			Detail: detail,
+			Advice: "wait for the rate limit to reset; consider a longer poll interval, or the v4 api",
+		}
+	case float64(core.Remaining) < float64(core.Limit)*RATE_LIMIT_WARNING_RATIO:
+		return CheckResult{
+			Name:   "rate limit",
+			Status: CHECK_WARN,
+			Detail: detail,
+			Advice: "other tools may be sharing this token's rate limit; consider a longer poll interval, or the v4 api",
+		}
+	}
+
+	return CheckResult{Name: "rate limit", Status: CHECK_PASS, Detail: detail}
+}
+
+func (doctor *Doctor) checkOrganisations(ctx context.Context) CheckResult {
+	// Organisations which enforce SAML SSO are omitted - and listed in the SSO
+	// header - until the token has been authorised for them.
+	orgs, resp, err := doctor.source.client.Organizations.List(ctx, "", &github.ListOptions{PerPage: DOCTOR_ORGS_PAGE_SIZE})
+	if err = doctor.source.observeREST(resp, err); err != nil {
+		return CheckResult{
+			Name:   "organisations",
+			Status: CHECK_WARN,
+			Detail: err.Error(),
+			Advice: "the token may lack the read:org scope; review requests made via teams won't be visible",
+		}
+	}
+
+	names := make([]string, len(orgs))
+	for idx, org := range orgs {
+		names[idx] = org.GetLogin()
+	}
+
+	detail := "none"
+	if len(names) > 0 {
+		detail = strings.Join(names, ", ")
+	}
+
+	if sso := resp.Header.Get(SSO_HEADER); sso != "" {
+		return CheckResult{
+			Name:   "organisations",
+			Status: CHECK_FAIL,
+			Detail: fmt.Sprintf("reachable: %s; sso authorization required (%s)", detail, sso),
+			Advice: "authorise the token for single sign-on, via 'Configure SSO' on the token's settings page",
+		}
+	}
+
+	return CheckResult{Name: "organisations", Status: CHECK_PASS, Detail: "reachable: " + detail}
+}
+
+func (doctor *Doctor) checkPrivateRepositories(ctx context.Context, scopes []string) CheckResult {
+	repos, resp, err := doctor.source.client.Repositories.List(ctx, "", &github.RepositoryListOptions{
+		Visibility:  "private",
+		ListOptions: github.ListOptions{PerPage: 1},
+	})
+	if err = doctor.source.observeREST(resp, err); err != nil {
+		return CheckResult{Name: "private repositories", Status: CHECK_WARN, Detail: err.Error()}
+	}
+
+	if len(repos) > 0 {
+		return CheckResult{Name: "private repositories", Status: CHECK_PASS, Detail: "private repositories are accessible"}
+	}
+
+	// Without any private repositories, the lack of access may be entirely
+	// expected; unless the token lacks the `repo` scope.
+	if scopes != nil && len(MissingScopes(scopes, []string{"repo"})) > 0 {
+		return CheckResult{
+			Name:   "private repositories",
+			Status: CHECK_FAIL,
+			Detail: "no private repositories are accessible",
+			Advice: "generate a token with the repo scope; pull requests in private repositories won't be visible",
+		}
+	}
+
+	return CheckResult{
+		Name:   "private repositories",
+		Status: CHECK_WARN,
+		Detail: "no private repositories are accessible",
+		Advice: "if you expect access to private repositories, check the token's sso authorization",
+	}
+}
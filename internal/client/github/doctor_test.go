@@ -0,0 +1,100 @@
+package github
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"testing"
+	"time"
+)
+
+func TestDoctorRun(t *testing.T) {
+	tests := []struct {
+		name      string
+		scopes    string
+		sso       string
+		remaining int
+		private   bool
+		clock     Clock
+		statuses  map[string]string
+	}{
+		{
+			name:      "healthy",
+			scopes:    "repo, read:org",
+			remaining: 4999,
+			private:   true,
+			statuses: map[string]string{
+				"host": CHECK_PASS, "authentication": CHECK_PASS, "scopes": CHECK_PASS, "clock": CHECK_PASS,
+				"rate limit": CHECK_PASS, "organisations": CHECK_PASS, "private repositories": CHECK_PASS,
+			},
+		},
+		{
+			name:      "missing scopes, sso, skew and a low rate limit",
+			scopes:    "read:org",
+			sso:       "required; url=https://github.com/orgs/o/sso",
+			remaining: 100,
+			clock:     newFakeClock(),
+			statuses: map[string]string{
+				"host": CHECK_PASS, "authentication": CHECK_PASS, "scopes": CHECK_FAIL, "clock": CHECK_FAIL,
+				"rate limit": CHECK_WARN, "organisations": CHECK_FAIL, "private repositories": CHECK_FAIL,
+			},
+		},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			mux := http.NewServeMux()
+			mux.HandleFunc("/api/v3/meta", func(w http.ResponseWriter, r *http.Request) {
+				writeJSON(t, w, map[string]interface{}{})
+			})
+			mux.HandleFunc("/api/v3/user", func(w http.ResponseWriter, r *http.Request) {
+				w.Header().Set(SCOPES_HEADER, test.scopes)
+				writeJSON(t, w, map[string]interface{}{"login": "octocat"})
+			})
+			mux.HandleFunc("/api/v3/rate_limit", func(w http.ResponseWriter, r *http.Request) {
+				writeJSON(t, w, map[string]interface{}{"resources": map[string]interface{}{
+					"core": map[string]interface{}{"limit": 5000, "remaining": test.remaining, "reset": time.Now().Add(time.Hour).Unix()},
+				}})
+			})
+			mux.HandleFunc("/api/v3/user/orgs", func(w http.ResponseWriter, r *http.Request) {
+				if test.sso != "" {
+					w.Header().Set(SSO_HEADER, test.sso)
+				}
+				writeJSON(t, w, []interface{}{map[string]interface{}{"login": "o"}})
+			})
+			mux.HandleFunc("/api/v3/user/repos", func(w http.ResponseWriter, r *http.Request) {
+				repos := []interface{}{}
+				if test.private {
+					repos = append(repos, map[string]interface{}{"name": "r", "private": true})
+				}
+				writeJSON(t, w, repos)
+			})
+
+			server := httptest.NewServer(mux)
+			defer server.Close()
+
+			serverURL, _ := url.Parse(server.URL)
+			endpoints, err := NewEndpoints(serverURL.Host, server.URL, "")
+			if err != nil {
+				t.Fatal(err)
+			}
+
+			doctor, err := NewDoctor(context.Background(), endpoints, "token", test.clock)
+			if err != nil {
+				t.Fatal(err)
+			}
+
+			results := doctor.Run(context.Background())
+			if len(results) != len(test.statuses) {
+				t.Fatalf("expected %d checks, found %+v", len(test.statuses), results)
+			}
+
+			for _, result := range results {
+				if expected := test.statuses[result.Name]; result.Status != expected {
+					t.Errorf("expected the %s check to %s, found %s: %s", result.Name, expected, result.Status, result.Detail)
+				}
+			}
+		})
+	}
+}
@@ -0,0 +1,158 @@
+package github
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"net/url"
+	"strings"
+	"time"
+)
+
+const (
+	// DEFAULT_HOST is the host of the public Github instance.
+	DEFAULT_HOST = "github.com"
+	// DEFAULT_BASE_URL is the URL for the v3 API of the public Github instance.
+	DEFAULT_BASE_URL = "https://api.github.com/"
+	// DEFAULT_UPLOAD_URL is the URL for uploads to the public Github instance.
+	DEFAULT_UPLOAD_URL = "https://uploads.github.com/"
+	// DEFAULT_WEB_URL is the URL of the web interface of the public Github
+	// instance; this also serves the OAuth endpoints.
+	DEFAULT_WEB_URL = "https://github.com/"
+	// ENTERPRISE_API_PATH is the path of the v3 API on a Github Enterprise Server.
+	ENTERPRISE_API_PATH = "api/v3/"
+	// ENTERPRISE_UPLOAD_PATH is the path for uploads on a Github Enterprise Server.
+	ENTERPRISE_UPLOAD_PATH = "api/uploads/"
+	// ENTERPRISE_GRAPHQL_PATH is the path of the v4 API on a Github Enterprise
+	// Server.
+	ENTERPRISE_GRAPHQL_PATH = "api/graphql"
+	// HOST_CHECK_TIMEOUT is the maximum time to wait when checking that a host is
+	// reachable.
+	HOST_CHECK_TIMEOUT = 10 * time.Second
+)
+
+// Endpoints contains the URLs used to access the Github API; either for the
+// public Github instance, or a Github Enterprise Server (GHES) instance.
+type Endpoints struct {
+	// Host of the Github instance - i.e "github.example.com"
+	Host string
+	// URL of the v3 (REST) API
+	BaseURL string
+	// URL used for uploads via the v3 API
+	UploadURL string
+	// URL of the v4 (GraphQL) API
+	GraphQLURL string
+	// URL of the web interface, which also serves the OAuth endpoints
+	WebURL string
+}
+
+// NewEndpoints generates - and validates - the Endpoints for a Github instance.
+// For a GHES instance either the `host` or the `baseURL` is required; the
+// `baseURL` takes precedence, and the `uploadURL` is derived from it if empty.
+// If neither are provided then the Endpoints for github.com are returned.
+func NewEndpoints(host, baseURL, uploadURL string) (Endpoints, error) {
+	if (host == "" || host == DEFAULT_HOST) && baseURL == "" {
+		return Endpoints{
+			Host:       DEFAULT_HOST,
+			BaseURL:    DEFAULT_BASE_URL,
+			UploadURL:  DEFAULT_UPLOAD_URL,
+			GraphQLURL: GRAPHQL_ENDPOINT,
+			WebURL:     DEFAULT_WEB_URL,
+		}, nil
+	}
+
+	if baseURL == "" {
+		baseURL = "https://" + host
+	}
+
+	base, err := parseEndpointURL(baseURL, ENTERPRISE_API_PATH)
+	if err != nil {
+		return Endpoints{}, fmt.Errorf("invalid base url: %w", err)
+	}
+
+	// The host may omit the port used by the base URL.
+	if host != "" && host != DEFAULT_HOST && !strings.EqualFold(host, base.Host) && !strings.EqualFold(host, base.Hostname()) {
+		return Endpoints{}, fmt.Errorf("host '%s' doesn't match base url '%s'", host, base)
+	}
+
+	if uploadURL == "" {
+		uploadURL = base.Scheme + "://" + base.Host + strings.TrimSuffix(base.Path, ENTERPRISE_API_PATH)
+	}
+
+	upload, err := parseEndpointURL(uploadURL, ENTERPRISE_UPLOAD_PATH)
+	if err != nil {
+		return Endpoints{}, fmt.Errorf("invalid upload url: %w", err)
+	}
+
+	// The GraphQL API is served alongside the REST API, under the same prefix;
+	// as is the web interface.
+	graphQL := *base
+	graphQL.Path = strings.TrimSuffix(base.Path, ENTERPRISE_API_PATH) + ENTERPRISE_GRAPHQL_PATH
+	web := *base
+	web.Path = strings.TrimSuffix(base.Path, ENTERPRISE_API_PATH)
+
+	return Endpoints{
+		Host:       base.Host,
+		BaseURL:    base.String(),
+		UploadURL:  upload.String(),
+		GraphQLURL: graphQL.String(),
+		WebURL:     web.String(),
+	}, nil
+}
+
+// Enterprise determines whether the Endpoints belong to a GHES instance.
+func (endpoints Endpoints) Enterprise() bool {
+	return endpoints.Host != "" && endpoints.Host != DEFAULT_HOST
+}
+
+// CheckHost verifies that the Github API is reachable, by requesting the meta
+// endpoint - which doesn't require authentication. This allows a misconfigured
+// host to be reported clearly, rather than as an authentication failure.
+func CheckHost(ctx context.Context, endpoints Endpoints) error {
+	ctx, cancel := context.WithTimeout(ctx, HOST_CHECK_TIMEOUT)
+	defer cancel()
+
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, endpoints.BaseURL+"meta", nil)
+	if err != nil {
+		return err
+	}
+
+	resp, err := http.DefaultClient.Do(req)
+	if err != nil {
+		return fmt.Errorf("unable to reach github host '%s': %w", endpoints.Host, err)
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode != http.StatusOK {
+		return fmt.Errorf("unexpected response from github host '%s': %s", endpoints.Host, resp.Status)
+	}
+
+	return nil
+}
+
+func parseEndpointURL(rawURL, apiPath string) (*url.URL, error) {
+	// Mirror `github.NewEnterpriseClient`, which appends the API path to any
+	// URL which doesn't already contain it.
+	parsed, err := url.Parse(rawURL)
+	if err != nil {
+		return nil, err
+	}
+
+	if parsed.Scheme != "http" && parsed.Scheme != "https" {
+		return nil, fmt.Errorf("'%s' must use http or https", rawURL)
+	}
+
+	if parsed.Host == "" {
+		return nil, fmt.Errorf("'%s' has no host", rawURL)
+	}
+
+	if !strings.HasSuffix(parsed.Path, "/") {
+		parsed.Path += "/"
+	}
+
+	if !strings.HasSuffix(parsed.Path, "/"+apiPath) {
+		parsed.Path += apiPath
+	}
+
+	return parsed, nil
+}
@@ -0,0 +1,114 @@
+package github
+
+import (
+	"strings"
+	"testing"
+)
+
+func TestNewEndpoints(t *testing.T) {
+	public := Endpoints{
+		Host:       DEFAULT_HOST,
+		BaseURL:    DEFAULT_BASE_URL,
+		UploadURL:  DEFAULT_UPLOAD_URL,
+		GraphQLURL: GRAPHQL_ENDPOINT,
+		WebURL:     DEFAULT_WEB_URL,
+	}
+
+	enterprise := Endpoints{
+		Host:       "ghes.example.com",
+		BaseURL:    "https://ghes.example.com/api/v3/",
+		UploadURL:  "https://ghes.example.com/api/uploads/",
+		GraphQLURL: "https://ghes.example.com/api/graphql",
+		WebURL:     "https://ghes.example.com/",
+	}
+
+	tests := []struct {
+		name      string
+		host      string
+		baseURL   string
+		uploadURL string
+		expected  Endpoints
+		err       string
+	}{
+		{name: "default", expected: public},
+		{name: "public host", host: DEFAULT_HOST, expected: public},
+		{name: "enterprise host", host: "ghes.example.com", expected: enterprise},
+		{name: "enterprise host, matching the base url", host: "GHES.example.com", baseURL: "https://ghes.example.com", expected: enterprise},
+		{name: "base url", baseURL: "https://ghes.example.com/", expected: enterprise},
+		{name: "base url with the api path", baseURL: "https://ghes.example.com/api/v3", expected: enterprise},
+		{
+			name: "host with a port",
+			host: "ghes.example.com:8443",
+			expected: Endpoints{
+				Host:       "ghes.example.com:8443",
+				BaseURL:    "https://ghes.example.com:8443/api/v3/",
+				UploadURL:  "https://ghes.example.com:8443/api/uploads/",
+				GraphQLURL: "https://ghes.example.com:8443/api/graphql",
+				WebURL:     "https://ghes.example.com:8443/",
+			},
+		},
+		{
+			name:    "host without the base url's port",
+			host:    "ghes.example.com",
+			baseURL: "http://ghes.example.com:8080/",
+			expected: Endpoints{
+				Host:       "ghes.example.com:8080",
+				BaseURL:    "http://ghes.example.com:8080/api/v3/",
+				UploadURL:  "http://ghes.example.com:8080/api/uploads/",
+				GraphQLURL: "http://ghes.example.com:8080/api/graphql",
+				WebURL:     "http://ghes.example.com:8080/",
+			},
+		},
+		{
+			name:    "base url with a prefix",
+			baseURL: "https://ghes.example.com/github/",
+			expected: Endpoints{
+				Host:       "ghes.example.com",
+				BaseURL:    "https://ghes.example.com/github/api/v3/",
+				UploadURL:  "https://ghes.example.com/github/api/uploads/",
+				GraphQLURL: "https://ghes.example.com/github/api/graphql",
+				WebURL:     "https://ghes.example.com/github/",
+			},
+		},
+		{
+			name:      "upload url",
+			host:      "ghes.example.com",
+			uploadURL: "https://uploads.example.com",
+			expected: Endpoints{
+				Host:       "ghes.example.com",
+				BaseURL:    "https://ghes.example.com/api/v3/",
+				UploadURL:  "https://uploads.example.com/api/uploads/",
+				GraphQLURL: "https://ghes.example.com/api/graphql",
+				WebURL:     "https://ghes.example.com/",
+			},
+		},
+		{name: "host mismatch", host: "other.example.com", baseURL: "https://ghes.example.com/", err: "doesn't match base url"},
+		{name: "unsupported scheme", baseURL: "ftp://ghes.example.com/", err: "must use http or https"},
+		{name: "base url without a host", baseURL: "https:///api/v3/", err: "has no host"},
+		{name: "invalid upload url", host: "ghes.example.com", uploadURL: "uploads.example.com", err: "invalid upload url"},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			endpoints, err := NewEndpoints(test.host, test.baseURL, test.uploadURL)
+			if test.err != "" {
+				if err == nil || !strings.Contains(err.Error(), test.err) {
+					t.Fatalf("expected the error '%s', found %v", test.err, err)
+				}
+				return
+			}
+
+			if err != nil {
+				t.Fatal(err)
+			}
+
+			if endpoints != test.expected {
+				t.Errorf("expected the endpoints %+v, found %+v", test.expected, endpoints)
+			}
+
+			if isEnterprise := test.expected.Host != DEFAULT_HOST; endpoints.Enterprise() != isEnterprise {
+				t.Errorf("expected Enterprise to be %t", isEnterprise)
+			}
+		})
+	}
+}
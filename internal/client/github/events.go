@@ -0,0 +1,128 @@
+package github
+
+import (
+	"strconv"
+	"time"
+)
+
+const (
+	// EVENT_OPENED indicates a Pull Request has appeared in any of the Poller's
+	// collections - i.e it's been opened, or assigned to the current user
+	EVENT_OPENED = "opened"
+	// EVENT_CLOSED indicates a Pull Request has been closed without being merged;
+	// should the source fail to resolve a Pull Request which has disappeared from
+	// the Poller's collections, then it's only observed once resolved - upon a
+	// subsequent poll
+	EVENT_CLOSED = "closed"
+	// EVENT_MERGED indicates a Pull Request has been merged; as with
+	// `EVENT_CLOSED`, it may be observed after the Pull Request has disappeared
+	EVENT_MERGED = "merged"
+	// EVENT_READY_FOR_REVIEW indicates a draft Pull Request is ready for review
+	EVENT_READY_FOR_REVIEW = "ready_for_review"
+	// EVENT_CONVERTED_TO_DRAFT indicates a Pull Request has become a draft
+	EVENT_CONVERTED_TO_DRAFT = "converted_to_draft"
+	// EVENT_REVIEW_REQUESTED indicates the current user's review has been
+	// requested
+	EVENT_REVIEW_REQUESTED = "review_requested"
+	// EVENT_REVIEW_SUBMITTED indicates a reviewer - the `Subject` of the Event -
+	// has submitted a review
+	EVENT_REVIEW_SUBMITTED = "review_submitted"
+	// EVENT_CI_FAILED indicates the CI of the head commit has failed or errored
+	EVENT_CI_FAILED = "ci_failed"
+	// EVENT_CI_PASSED indicates the CI of the head commit has succeeded
+	EVENT_CI_PASSED = "ci_passed"
+	// EVENT_NEW_COMMENT indicates new comments have been left on a Pull Request
+	EVENT_NEW_COMMENT = "new_comment"
+)
+
+// Event describes a change in the lifecycle of a Pull Request; such as it being
+// opened, reviewed, or failing CI.
+type Event struct {
+	// One of the `EVENT_` constants
+	Type string
+	// The Pull Request, as of the most recent poll; or the previous poll if
+	// it's no longer present
+	PullRequest PullRequestSummary
+	// What the Event applies to, if applicable - i.e the reviewer login for
+	// `EVENT_REVIEW_SUBMITTED`
+	Subject string
+	// Time of the poll which observed the Event
+	At time.Time
+}
+
+// NewEvents compares two successive sets of Pull Requests - across all of their
+// collections - and returns the Events which describe the changes between them.
+func NewEvents(previous, current *PullRequestSets, at time.Time) []Event {
+	events := make([]Event, 0)
+	emit := func(eventType string, pr PullRequestSummary, subject string) {
+		events = append(events, Event{Type: eventType, PullRequest: pr, Subject: subject, At: at})
+	}
+
+	// A Pull Request may be present in multiple collections, so each is only
+	// considered once; it's only closed once absent from every collection.
+	diff := NewDiff(previous.all(), current.all())
+	for _, pr := range diff.Added {
+		emit(EVENT_OPENED, pr, "")
+	}
+
+	// A Pull Request is closed once it's added to the recently closed collection;
+	// one which is no longer listed - but isn't recently closed - is either still
+	// open, and has simply been unassigned or similar, or yet to be resolved.
+	for _, pr := range NewDiff(previous.RecentlyClosed, current.RecentlyClosed).Added {
+		if pr.Status == STATUS_MERGED {
+			emit(EVENT_MERGED, pr, "")
+		} else {
+			emit(EVENT_CLOSED, pr, "")
+		}
+	}
+
+	for _, pr := range NewDiff(previous.ReviewRequested, current.ReviewRequested).Added {
+		emit(EVENT_REVIEW_REQUESTED, pr, "")
+	}
+
+	for _, change := range diff.Modified {
+		for _, field := range change.Fields {
+			switch {
+			case field.Field == FIELD_DRAFT && !change.Current.Draft:
+				emit(EVENT_READY_FOR_REVIEW, change.Current, "")
+			case field.Field == FIELD_DRAFT:
+				emit(EVENT_CONVERTED_TO_DRAFT, change.Current, "")
+			case field.Field == FIELD_REVIEW && field.Current != "":
+				emit(EVENT_REVIEW_SUBMITTED, change.Current, field.Subject)
+			case field.Field == FIELD_CI && (field.Current == CI_FAILURE || field.Current == CI_ERROR):
+				emit(EVENT_CI_FAILED, change.Current, "")
+			case field.Field == FIELD_CI && field.Current == CI_SUCCESS:
+				emit(EVENT_CI_PASSED, change.Current, "")
+			case field.Field == FIELD_COMMENTS:
+				if previousCount, _ := strconv.Atoi(field.Previous); change.Current.Comments > previousCount {
+					emit(EVENT_NEW_COMMENT, change.Current, "")
+				}
+			}
+		}
+	}
+
+	return events
+}
+
+// all returns the open Pull Requests from every collection, without duplicates.
+func (sets *PullRequestSets) all() []PullRequestSummary {
+	seen := make(map[string]struct{})
+	all := make([]PullRequestSummary, 0)
+	collect := func(pullRequests []PullRequestSummary) {
+		for _, pr := range pullRequests {
+			if _, isSeen := seen[pr.Key()]; !isSeen {
+				seen[pr.Key()] = struct{}{}
+				all = append(all, pr)
+			}
+		}
+	}
+
+	collect(sets.Assigned)
+	collect(sets.Created)
+	collect(sets.ReviewRequested)
+	for _, results := range sets.Searches {
+		collect(results)
+	}
+
+	return all
+}
@@ -0,0 +1,376 @@
+package github
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"strconv"
+	"strings"
+	"time"
+)
+
+const (
+	// GRAPHQL_ENDPOINT is the URL for the Github v4 API.
+	GRAPHQL_ENDPOINT = "https://api.github.com/graphql"
+	// GRAPHQL_PAGE_SIZE is the maximum number of results requested per page of
+	// a search.
+	GRAPHQL_PAGE_SIZE = 100
+	// ASSIGNED_QUERY is the search query for open Pull Requests assigned to the
+	// current user. Unlike `ASSIGNED_FILTER` - used by the REST source, and which
+	// includes every open Pull Request in the user's repositories - the GraphQL
+	// API only offers search, so this is restricted to explicit assignments.
+	ASSIGNED_QUERY = "is:pr is:open assignee:@me"
+	// CREATED_QUERY is the search query for open Pull Requests created by the
+	// current user.
+	CREATED_QUERY = "is:pr is:open author:@me"
+)
+
+// viewerQuery retrieves the login of the currently authenticated user.
+const viewerQuery = `query { viewer { login } }`
+
+// pullRequestQuery retrieves the first page of all three sets of Pull Requests
+// in one request, via aliased searches. Each search shares the `results`
+// fragment, which contains everything required to populate a
+// `PullRequestSummary` - and to retrieve any further pages via `searchQuery`.
+const pullRequestQuery = `
+query($assigned: String!, $created: String!, $reviewRequested: String!, $first: Int!) {
+  assigned: search(query: $assigned, type: ISSUE, first: $first) { ...results }
+  created: search(query: $created, type: ISSUE, first: $first) { ...results }
+  reviewRequested: search(query: $reviewRequested, type: ISSUE, first: $first) { ...results }
+}
+` + resultsFragment + summaryFragment
+
+// searchQuery retrieves a page of the Pull Requests matching an arbitrary search
+// query; the first page is retrieved when `$after` is null.
+const searchQuery = `
+query($query: String!, $first: Int!, $after: String) {
+  results: search(query: $query, type: ISSUE, first: $first, after: $after) { ...results }
+}
+` + resultsFragment + summaryFragment
+
+// resultsFragment contains a page of search results, and the cursor required to
+// retrieve the next page.
+const resultsFragment = `
+fragment results on SearchResultItemConnection {
+  pageInfo { hasNextPage endCursor }
+  nodes { ...summary }
+}`
+
+// summaryFragment contains all the fields required for a `PullRequestSummary`.
+const summaryFragment = `
+fragment summary on PullRequest {
+  number
+  title
+  url
+  isDraft
+  state
+  createdAt
+  author { login }
+  repository { name }
+  comments { totalCount }
+  reviewRequests(first: $first) {
+    nodes { requestedReviewer { ... on User { login } ... on Team { slug } } }
+  }
+  reviews(last: $first) { nodes { state author { login } } }
+  commits(last: 1) { nodes { commit { statusCheckRollup { state } } } }
+}`
+
+// GraphQLSource retrieves Pull Requests via the v4 (GraphQL) API; unlike the
+// REST implementation, it requires just the one request per poll - unless any
+// of the sets span multiple pages.
+type GraphQLSource struct {
+	rateLimitTracker
+	httpClient *http.Client
+	endpoint   string
+	// Maximum number of Pull Requests retrieved per search; this is a safety
+	// cap on the number of pages requested.
+	MaxItems int
+}
+
+// NewGraphQLSource returns a `GraphQLSource` which uses the provided (and
+// authenticated) `http.Client` to query the GraphQL API at `endpoint`.
+func NewGraphQLSource(httpClient *http.Client, endpoint string) *GraphQLSource {
+	return &GraphQLSource{
+		httpClient: httpClient,
+		endpoint:   endpoint,
+		MaxItems:   DEFAULT_MAX_ITEMS,
+	}
+}
+
+type graphQLRequest struct {
+	Query     string                 `json:"query"`
+	Variables map[string]interface{} `json:"variables"`
+}
+
+type graphQLResponse struct {
+	Data   json.RawMessage `json:"data"`
+	Errors []struct {
+		Message string `json:"message"`
+	} `json:"errors"`
+}
+
+type graphQLViewer struct {
+	Viewer struct {
+		Login string `json:"login"`
+	} `json:"viewer"`
+}
+
+type graphQLPullRequestSets struct {
+	Assigned        graphQLSearch `json:"assigned"`
+	Created         graphQLSearch `json:"created"`
+	ReviewRequested graphQLSearch `json:"reviewRequested"`
+}
+
+type graphQLSearchResults struct {
+	Results graphQLSearch `json:"results"`
+}
+
+type graphQLSearch struct {
+	PageInfo struct {
+		HasNextPage bool   `json:"hasNextPage"`
+		EndCursor   string `json:"endCursor"`
+	} `json:"pageInfo"`
+	Nodes []graphQLPullRequest `json:"nodes"`
+}
+
+type graphQLPullRequest struct {
+	Number    int       `json:"number"`
+	Title     string    `json:"title"`
+	URL       string    `json:"url"`
+	IsDraft   bool      `json:"isDraft"`
+	State     string    `json:"state"`
+	CreatedAt time.Time `json:"createdAt"`
+	Author    struct {
+		Login string `json:"login"`
+	} `json:"author"`
+	Repository struct {
+		Name string `json:"name"`
+	} `json:"repository"`
+	Comments struct {
+		TotalCount int `json:"totalCount"`
+	} `json:"comments"`
+	ReviewRequests struct {
+		Nodes []struct {
+			RequestedReviewer struct {
+				Login string `json:"login"`
+				Slug  string `json:"slug"`
+			} `json:"requestedReviewer"`
+		} `json:"nodes"`
+	} `json:"reviewRequests"`
+	Reviews struct {
+		Nodes []struct {
+			State  string `json:"state"`
+			Author struct {
+				Login string `json:"login"`
+			} `json:"author"`
+		} `json:"nodes"`
+	} `json:"reviews"`
+	Commits struct {
+		Nodes []struct {
+			Commit struct {
+				StatusCheckRollup *struct {
+					State string `json:"state"`
+				} `json:"statusCheckRollup"`
+			} `json:"commit"`
+		} `json:"nodes"`
+	} `json:"commits"`
+}
+
+func (node graphQLPullRequest) ciState() string {
+	// The rollup is null when the head commit has no statuses or check runs.
+	for _, commit := range node.Commits.Nodes {
+		if rollup := commit.Commit.StatusCheckRollup; rollup != nil {
+			return normaliseCIState(rollup.State)
+		}
+	}
+
+	return CI_NONE
+}
+
+func (node graphQLPullRequest) reviewSummary() ReviewSummary {
+	// Review requests may be for either a User or a Team; the fragments ensure
+	// only the relevant field is populated.
+	requestedReviewers, requestedTeams := make([]string, 0), make([]string, 0)
+	for _, request := range node.ReviewRequests.Nodes {
+		if request.RequestedReviewer.Login != "" {
+			requestedReviewers = append(requestedReviewers, request.RequestedReviewer.Login)
+		} else if request.RequestedReviewer.Slug != "" {
+			requestedTeams = append(requestedTeams, request.RequestedReviewer.Slug)
+		}
+	}
+
+	submitted := make([]submittedReview, len(node.Reviews.Nodes))
+	for idx, review := range node.Reviews.Nodes {
+		submitted[idx] = submittedReview{
+			login: review.Author.Login,
+			state: review.State,
+		}
+	}
+
+	return NewReviewSummary(requestedReviewers, requestedTeams, latestReviewStates(submitted))
+}
+
+// CurrentUser retrieves the login of the authenticated user via the `viewer`.
+func (source *GraphQLSource) CurrentUser(ctx context.Context) (string, error) {
+	var result graphQLViewer
+	if err := source.query(ctx, viewerQuery, nil, &result); err != nil {
+		return "", err
+	}
+
+	return result.Viewer.Login, nil
+}
+
+// PullRequests executes the aliased search query against the GraphQL endpoint,
+// converting each set of results into `PullRequestSummary` structs; any
+// further pages are then retrieved individually.
+func (source *GraphQLSource) PullRequests(ctx context.Context) (*PullRequestSets, error) {
+	var result graphQLPullRequestSets
+	if err := source.query(ctx, pullRequestQuery, map[string]interface{}{
+		"assigned":        ASSIGNED_QUERY,
+		"created":         CREATED_QUERY,
+		"reviewRequested": REVIEW_REQUESTED_QUERY,
+		"first":           GRAPHQL_PAGE_SIZE,
+	}, &result); err != nil {
+		return nil, err
+	}
+
+	sets := &PullRequestSets{}
+	for _, search := range []struct {
+		query     string
+		firstPage graphQLSearch
+		set       *[]PullRequestSummary
+	}{
+		{ASSIGNED_QUERY, result.Assigned, &sets.Assigned},
+		{CREATED_QUERY, result.Created, &sets.Created},
+		{REVIEW_REQUESTED_QUERY, result.ReviewRequested, &sets.ReviewRequested},
+	} {
+		summaries, err := source.paginate(ctx, search.query, search.firstPage)
+		if err != nil {
+			return nil, err
+		}
+
+		*search.set = summaries
+	}
+
+	return sets, nil
+}
+
+// Search executes an arbitrary search query against the GraphQL endpoint.
+func (source *GraphQLSource) Search(ctx context.Context, query string) ([]PullRequestSummary, error) {
+	var result graphQLSearchResults
+	if err := source.query(ctx, searchQuery, map[string]interface{}{
+		"query": query,
+		"first": GRAPHQL_PAGE_SIZE,
+		"after": nil,
+	}, &result); err != nil {
+		return nil, err
+	}
+
+	return source.paginate(ctx, query, result.Results)
+}
+
+func (source *GraphQLSource) paginate(ctx context.Context, query string, page graphQLSearch) ([]PullRequestSummary, error) {
+	// Follow the cursor of each page - starting with the first, which has already
+	// been retrieved - until either there are no further pages or we've retrieved
+	// `MaxItems` Pull Requests.
+	summaries := page.summaries()
+	for page.PageInfo.HasNextPage && page.PageInfo.EndCursor != "" &&
+		(source.MaxItems <= 0 || len(summaries) < source.MaxItems) {
+		var result graphQLSearchResults
+		if err := source.query(ctx, searchQuery, map[string]interface{}{
+			"query": query,
+			"first": GRAPHQL_PAGE_SIZE,
+			"after": page.PageInfo.EndCursor,
+		}, &result); err != nil {
+			return nil, err
+		}
+
+		page = result.Results
+		summaries = append(summaries, page.summaries()...)
+	}
+
+	if source.MaxItems > 0 && len(summaries) > source.MaxItems {
+		summaries = summaries[:source.MaxItems]
+	}
+
+	return summaries, nil
+}
+
+func (source *GraphQLSource) query(ctx context.Context, query string, variables map[string]interface{}, target interface{}) error {
+	// Execute a query, decoding the `data` field of the response into target;
+	// any errors reported by the API are combined into a single error.
+	body, err := json.Marshal(graphQLRequest{
+		Query:     query,
+		Variables: variables,
+	})
+	if err != nil {
+		return err
+	}
+
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, source.endpoint, bytes.NewReader(body))
+	if err != nil {
+		return err
+	}
+	req.Header.Set("Content-Type", "application/json")
+
+	resp, err := source.httpClient.Do(req)
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+
+	if err := source.observeHTTP(resp); err != nil {
+		return err
+	}
+
+	if resp.StatusCode != http.StatusOK {
+		return fmt.Errorf("graphql: unexpected response status %s", resp.Status)
+	}
+
+	var result graphQLResponse
+	if err := json.NewDecoder(resp.Body).Decode(&result); err != nil {
+		return err
+	}
+
+	if len(result.Errors) > 0 {
+		messages := make([]string, len(result.Errors))
+		for idx, graphQLErr := range result.Errors {
+			messages[idx] = graphQLErr.Message
+		}
+		return errors.New("graphql: " + strings.Join(messages, "; "))
+	}
+
+	return json.Unmarshal(result.Data, target)
+}
+
+func (search graphQLSearch) summaries() []PullRequestSummary {
+	// Search results can technically contain Issues as well; these have no
+	// fields in common with the fragment, and so are decoded as empty nodes.
+	collection := make([]PullRequestSummary, 0, len(search.Nodes))
+	for _, node := range search.Nodes {
+		if node.Number == 0 {
+			continue
+		}
+
+		review := node.reviewSummary()
+		collection = append(collection, PullRequestSummary{
+			Draft:         node.IsDraft,
+			Author:        node.Author.Login,
+			Title:         node.Title,
+			Repository:    node.Repository.Name,
+			ID:            strconv.Itoa(node.Number),
+			ReviewerCount: review.ReviewerCount(),
+			Review:        review,
+			Status:        strings.ToLower(node.State),
+			CIState:       node.ciState(),
+			Comments:      node.Comments.TotalCount,
+			OpenedAt:      node.CreatedAt,
+			URL:           node.URL,
+		})
+	}
+
+	return collection
+}
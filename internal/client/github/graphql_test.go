@@ -0,0 +1,317 @@
+package github
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"reflect"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+)
+
+// newTestGraphQLSource returns a `GraphQLSource` whose requests are decoded and
+// passed to `respond`, rather than being made to the Github API.
+func newTestGraphQLSource(t *testing.T, respond func(w http.ResponseWriter, request graphQLRequest)) *GraphQLSource {
+	t.Helper()
+
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.Method != http.MethodPost || r.Header.Get("Content-Type") != "application/json" {
+			t.Errorf("unexpected %s request with the content type '%s'", r.Method, r.Header.Get("Content-Type"))
+		}
+
+		var request graphQLRequest
+		if err := json.NewDecoder(r.Body).Decode(&request); err != nil {
+			t.Error(err)
+		}
+
+		respond(w, request)
+	}))
+	t.Cleanup(server.Close)
+
+	return NewGraphQLSource(server.Client(), server.URL)
+}
+
+// graphQLNode returns the representation of Pull Request `number`, as returned
+// by the `summary` fragment.
+func graphQLNode(number int) map[string]interface{} {
+	return map[string]interface{}{
+		"number":     number,
+		"title":      fmt.Sprintf("Pull Request %d", number),
+		"url":        fmt.Sprintf("https://github.com/o/r/pull/%d", number),
+		"isDraft":    false,
+		"state":      "OPEN",
+		"createdAt":  "2020-01-01T00:00:00Z",
+		"author":     map[string]interface{}{"login": "author"},
+		"repository": map[string]interface{}{"name": "r"},
+		"comments":   map[string]interface{}{"totalCount": 3},
+		"reviewRequests": map[string]interface{}{"nodes": []interface{}{
+			map[string]interface{}{"requestedReviewer": map[string]interface{}{"login": "reviewer"}},
+		}},
+		"reviews": map[string]interface{}{"nodes": []interface{}{
+			map[string]interface{}{"state": "APPROVED", "author": map[string]interface{}{"login": "approver"}},
+		}},
+		"commits": map[string]interface{}{"nodes": []interface{}{
+			map[string]interface{}{"commit": map[string]interface{}{"statusCheckRollup": map[string]interface{}{"state": "FAILURE"}}},
+		}},
+	}
+}
+
+// graphQLPage returns a page of search results containing the numbered Pull
+// Requests; it's followed by another page, unless `cursor` is empty.
+func graphQLPage(cursor string, numbers ...int) map[string]interface{} {
+	nodes := make([]interface{}, len(numbers))
+	for idx, number := range numbers {
+		nodes[idx] = graphQLNode(number)
+	}
+
+	return map[string]interface{}{
+		"pageInfo": map[string]interface{}{"hasNextPage": cursor != "", "endCursor": cursor},
+		"nodes":    nodes,
+	}
+}
+
+func ids(pullRequests []PullRequestSummary) []string {
+	ids := make([]string, len(pullRequests))
+	for idx, pr := range pullRequests {
+		ids[idx] = pr.ID
+	}
+
+	return ids
+}
+
+func TestGraphQLSourceCurrentUser(t *testing.T) {
+	source := newTestGraphQLSource(t, func(w http.ResponseWriter, request graphQLRequest) {
+		if request.Query != viewerQuery {
+			t.Errorf("unexpected query %s", request.Query)
+		}
+
+		writeJSON(t, w, map[string]interface{}{
+			"data": map[string]interface{}{"viewer": map[string]interface{}{"login": "octocat"}},
+		})
+	})
+
+	username, err := source.CurrentUser(context.Background())
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if username != "octocat" {
+		t.Fatalf("expected the viewer's login, found '%s'", username)
+	}
+}
+
+func TestGraphQLSourcePullRequests(t *testing.T) {
+	source := newTestGraphQLSource(t, func(w http.ResponseWriter, request graphQLRequest) {
+		switch {
+		case request.Query == pullRequestQuery:
+			if request.Variables["assigned"] != ASSIGNED_QUERY || request.Variables["created"] != CREATED_QUERY ||
+				request.Variables["reviewRequested"] != REVIEW_REQUESTED_QUERY {
+				t.Errorf("unexpected variables %v", request.Variables)
+			}
+
+			writeJSON(t, w, map[string]interface{}{"data": map[string]interface{}{
+				"assigned":        graphQLPage("page-2", 1, 2),
+				"created":         graphQLPage("", 3),
+				"reviewRequested": graphQLPage("", 4),
+			}})
+		case request.Query == searchQuery && request.Variables["query"] == ASSIGNED_QUERY && request.Variables["after"] == "page-2":
+			writeJSON(t, w, map[string]interface{}{"data": map[string]interface{}{
+				"results": graphQLPage("", 5),
+			}})
+		default:
+			t.Errorf("unexpected request for %v", request.Variables)
+			http.Error(w, "unexpected request", http.StatusBadRequest)
+		}
+	})
+
+	sets, err := source.PullRequests(context.Background())
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	for _, set := range []struct {
+		name     string
+		received []PullRequestSummary
+		expected []string
+	}{
+		{"assigned", sets.Assigned, []string{"1", "2", "5"}},
+		{"created", sets.Created, []string{"3"}},
+		{"review requested", sets.ReviewRequested, []string{"4"}},
+	} {
+		if received := ids(set.received); !reflect.DeepEqual(received, set.expected) {
+			t.Errorf("expected the %s Pull Requests %v, found %v", set.name, set.expected, received)
+		}
+	}
+
+	pr := sets.Created[0]
+	if pr.Status != STATUS_OPEN || pr.CIState != CI_FAILURE || pr.ReviewerCount != 2 || pr.Comments != 3 ||
+		pr.Repository != "r" || pr.Author != "author" || !pr.OpenedAt.Equal(time.Date(2020, time.January, 1, 0, 0, 0, 0, time.UTC)) {
+		t.Errorf("unexpected summary %+v", pr)
+	}
+}
+
+func TestGraphQLSourceSearchPagination(t *testing.T) {
+	tests := []struct {
+		name     string
+		maxItems int
+		pages    int
+		results  int
+		requests int
+	}{
+		{name: "single page", maxItems: 10, pages: 1, results: 2, requests: 1},
+		{name: "every page", maxItems: 10, pages: 3, results: 6, requests: 3},
+		{name: "capped mid-page", maxItems: 3, pages: 5, results: 3, requests: 2},
+		{name: "capped at a page boundary", maxItems: 4, pages: 5, results: 4, requests: 2},
+		{name: "uncapped", maxItems: 0, pages: 4, results: 8, requests: 4},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			var mutex sync.Mutex
+			requests := 0
+
+			// Each page contains two Pull Requests; the cursor is the page number.
+			source := newTestGraphQLSource(t, func(w http.ResponseWriter, request graphQLRequest) {
+				mutex.Lock()
+				requests++
+				mutex.Unlock()
+
+				page := 1
+				if after, isSet := request.Variables["after"].(string); isSet {
+					fmt.Sscan(after, &page)
+				}
+
+				cursor := ""
+				if page < test.pages {
+					cursor = fmt.Sprint(page + 1)
+				}
+
+				writeJSON(t, w, map[string]interface{}{"data": map[string]interface{}{
+					"results": graphQLPage(cursor, 2*page-1, 2*page),
+				}})
+			})
+			source.MaxItems = test.maxItems
+
+			results, err := source.Search(context.Background(), "is:pr is:open")
+			if err != nil {
+				t.Fatal(err)
+			}
+
+			if len(results) != test.results {
+				t.Errorf("expected %d results, found %d", test.results, len(results))
+			}
+
+			for idx, pr := range results {
+				if pr.ID != fmt.Sprint(idx+1) {
+					t.Fatalf("expected the results in order, found %v", ids(results))
+				}
+			}
+
+			if requests != test.requests {
+				t.Errorf("expected %d requests, found %d", test.requests, requests)
+			}
+		})
+	}
+}
+
+func TestGraphQLSourceErrors(t *testing.T) {
+	reset := time.Unix(1600000000, 0)
+
+	tests := []struct {
+		name       string
+		status     int
+		headers    map[string]string
+		body       interface{}
+		message    string
+		reset      time.Time
+		retryAfter time.Duration
+	}{
+		{
+			name:    "errors array",
+			status:  http.StatusOK,
+			body:    map[string]interface{}{"errors": []interface{}{map[string]string{"message": "first"}, map[string]string{"message": "second"}}},
+			message: "graphql: first; second",
+		},
+		{
+			name:    "rate limit exhausted",
+			status:  http.StatusForbidden,
+			headers: map[string]string{"X-RateLimit-Limit": "5000", "X-RateLimit-Remaining": "0", "X-RateLimit-Reset": "1600000000"},
+			reset:   reset,
+		},
+		{
+			name:       "secondary rate limit",
+			status:     http.StatusForbidden,
+			headers:    map[string]string{"Retry-After": "30"},
+			retryAfter: 30 * time.Second,
+		},
+		{
+			name:    "forbidden",
+			status:  http.StatusForbidden,
+			headers: map[string]string{"X-RateLimit-Limit": "5000", "X-RateLimit-Remaining": "4000", "X-RateLimit-Reset": "1600000000"},
+			message: "graphql: unexpected response status 403 Forbidden",
+		},
+		{
+			name:    "server error",
+			status:  http.StatusBadGateway,
+			message: "graphql: unexpected response status 502 Bad Gateway",
+		},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			source := newTestGraphQLSource(t, func(w http.ResponseWriter, request graphQLRequest) {
+				for header, value := range test.headers {
+					w.Header().Set(header, value)
+				}
+
+				w.WriteHeader(test.status)
+				if test.body != nil {
+					writeJSON(t, w, test.body)
+				}
+			})
+
+			_, err := source.CurrentUser(context.Background())
+			if err == nil {
+				t.Fatal("expected an error")
+			}
+
+			var rateErr *RateLimitError
+			isRateLimited := errors.As(err, &rateErr)
+			if test.message != "" {
+				if isRateLimited || !strings.Contains(err.Error(), test.message) {
+					t.Fatalf("expected the error '%s', found '%v'", test.message, err)
+				}
+				return
+			}
+
+			if !isRateLimited || !rateErr.Reset.Equal(test.reset) || rateErr.RetryAfter != test.retryAfter {
+				t.Fatalf("expected a RateLimitError resetting at %s, or after %s; found %#v", test.reset, test.retryAfter, err)
+			}
+		})
+	}
+}
+
+func TestGraphQLSourceRateLimit(t *testing.T) {
+	source := newTestGraphQLSource(t, func(w http.ResponseWriter, request graphQLRequest) {
+		w.Header().Set("X-RateLimit-Limit", "5000")
+		w.Header().Set("X-RateLimit-Remaining", "4999")
+		w.Header().Set("X-RateLimit-Reset", "1600000000")
+		writeJSON(t, w, map[string]interface{}{
+			"data": map[string]interface{}{"viewer": map[string]interface{}{"login": "octocat"}},
+		})
+	})
+
+	if _, err := source.CurrentUser(context.Background()); err != nil {
+		t.Fatal(err)
+	}
+
+	expected := RateLimit{Limit: 5000, Remaining: 4999, Reset: time.Unix(1600000000, 0)}
+	if rate := source.RateLimit(); rate.Limit != expected.Limit || rate.Remaining != expected.Remaining || !rate.Reset.Equal(expected.Reset) {
+		t.Fatalf("expected the rate limit %+v, found %+v", expected, rate)
+	}
+}
@@ -5,19 +5,18 @@
 // Additional functionality (in the way of change detection) is also
 // included in this repository.
 //
-// Note that the implementation isn't ideal, and a likely improvement
-// will be the usage of the Github v4 API (GraphQL based) to reduce
-// the number of requests required, and also migrate away from `go-github`
-// which is full of a lot of auto-generated noise.
+// Pull Requests are retrieved via a `PullRequestSource`, of which there
+// are two implementations: the original v3 (REST) implementation - which
+// requires a request per filter *and* per Pull Request - and a v4 (GraphQL)
+// implementation which retrieves everything via a single request.
 package github
 
 import (
 	"context"
+	"errors"
+	"fmt"
 	"sync"
 	"time"
-
-	"github.com/google/go-github/v32/github"
-	"golang.org/x/oauth2"
 )
 
 // Gotcha alert! You would imagine that `created` Issues would be a subset of
@@ -29,125 +28,342 @@ const (
 	ASSIGNED_FILTER = "all"
 	// CREATED_FILTER specifies that ONLY CREATED Issues should be returned from Github
 	CREATED_FILTER = "created"
+	// REVIEW_REQUESTED_QUERY is the search query for open Pull Requests where
+	// the current user - or one of their teams - has been requested to review
+	REVIEW_REQUESTED_QUERY = "is:open is:pr archived:false review-requested:@me"
+	// TEAM_REVIEW_REQUESTED_QUERY is the search query for open Pull Requests
+	// where a specific team - as `org/team-slug` - has been requested to review
+	TEAM_REVIEW_REQUESTED_QUERY = "is:open is:pr archived:false team-review-requested:%s"
 )
 
-// PollerNotificationChannels is a wrapper around two channels used for notifying
-// calling code of new data being made available via the Poller.
-type PollerNotificationChannels struct {
-	// LatestPollTimestamp updates the calling code whenever a new Poll is made
-	LatestPollTimestamp chan time.Time
-	// NewDataAvailable informs the calling code that a difference has been detected
-	// in the most recent API response.
-	NewDataAvailable chan struct{}
-}
-
-// NewPollerNotificationChannels provides a ready-to-use `PollerNotificationChannels`.
-func NewPollerNotificationChannels() *PollerNotificationChannels {
-	return &PollerNotificationChannels{
-		LatestPollTimestamp: make(chan time.Time),
-		NewDataAvailable:    make(chan struct{}),
-	}
-}
-
 // Poller regularly polls the Github API for new Pull Requests, and
-// communicates any changes via a `PollerNotificationChannels` struct.
+// communicates the outcome of each poll to its subscribers - see `Subscribe`.
 // Poller embeds a RWMutex, this is used when updating the internal
-// PullRequest collections.
+// PullRequest collections; consumers should generally use `Snapshot` rather
+// than reading the collections directly.
 type Poller struct {
 	sync.Mutex
-	ctx    context.Context
-	client *github.Client
+	ctx      context.Context
+	source   PullRequestSource
+	clock    Clock
+	searches []SavedSearch
+	notifier broadcaster
+	snapshot *Snapshot
+	refresh  chan struct{}
+	// Pull Requests which the source failed to resolve; only accessed by `Poll`
+	unresolved []unresolvedPullRequest
 	// Timestamp of the last time the Poller queries the Github API
 	LastPolled time.Time
 	// Username - or 'Login' - of the currently authenticated Github user
 	Username string
+	// How long closed - and merged - Pull Requests are retained in the recently
+	// closed collection; this may be changed before calling `Poll`
+	ClosedWindow time.Duration
 	// Collection of Pull Requests assigned to the current user
 	AssignedPullRequests *PullRequestSummaryCollection
 	// Collection of Pull Requests *created* by the current user
 	CreatedPullRequests *PullRequestSummaryCollection
+	// Collection of Pull Requests where the current user's review is requested
+	ReviewRequestedPullRequests *PullRequestSummaryCollection
+	// Collections of Pull Requests matching each `SavedSearch`, in the same
+	// order as the searches provided to `NewPoller`
+	SearchPullRequests []*PullRequestSummaryCollection
+	// Collection of Pull Requests which - having been in any of the other
+	// collections - were closed or merged within the `ClosedWindow`
+	RecentlyClosedPullRequests *PullRequestSummaryCollection
 }
 
-// NewPoller configures a new `Poller` struct, authenticating with Github and
-// making the initial API request. The returned `Poller` is fully populated
-// data, however is not configured to Poll automatically - this happens after
-// `Poll` is called.
-func NewPoller(ctx context.Context, token string) *Poller {
-	oauthClient := oauth2.NewClient(ctx, oauth2.StaticTokenSource(
-		&oauth2.Token{
-			AccessToken: token,
-		},
-	))
-
-	ghClient := github.NewClient(oauthClient)
-	currentUser, _, err := ghClient.Users.Get(ctx, "")
+// NewPoller configures a new `Poller` struct, retrieving the current user
+// from the `PullRequestSource` and making the initial API request. The returned
+// `Poller` is fully populated data, however is not configured to Poll
+// automatically - this happens after `Poll` is called. An error is returned
+// if either the current user or the initial set of Pull Requests can't be
+// retrieved. Each `SavedSearch` is maintained as an additional collection. If
+// a `Clock` is not explicitly provided, then the system clock will be used.
+func NewPoller(ctx context.Context, source PullRequestSource, searches []SavedSearch, clock Clock) (*Poller, error) {
+	if clock == nil {
+		clock = realClock{}
+	}
+
+	username, err := source.CurrentUser(ctx)
 	if err != nil {
-		panic(err)
+		return nil, fmt.Errorf("unable to retrieve current user: %w", err)
 	}
 
 	poller := &Poller{
-		ctx:      ctx,
-		client:   ghClient,
-		Username: currentUser.GetLogin(),
+		ctx:          ctx,
+		source:       source,
+		clock:        clock,
+		searches:     searches,
+		refresh:      make(chan struct{}, 1),
+		Username:     username,
+		ClosedWindow: DEFAULT_CLOSED_WINDOW,
+	}
+
+	pullRequests, err := poller.pullRequests()
+	if err != nil {
+		return nil, err
 	}
 
-	assigned, created := poller.pullRequests(ASSIGNED_FILTER, CREATED_FILTER)
-	poller.AssignedPullRequests = NewPullRequestSummaryCollection(assigned)
-	poller.CreatedPullRequests = NewPullRequestSummaryCollection(created)
-	poller.LastPolled = time.Now()
-	return poller
+	poller.AssignedPullRequests = NewPullRequestSummaryCollection(pullRequests.Assigned)
+	poller.CreatedPullRequests = NewPullRequestSummaryCollection(pullRequests.Created)
+	poller.ReviewRequestedPullRequests = NewPullRequestSummaryCollection(pullRequests.ReviewRequested)
+	poller.SearchPullRequests = make([]*PullRequestSummaryCollection, len(searches))
+	for idx, results := range pullRequests.Searches {
+		poller.SearchPullRequests[idx] = NewPullRequestSummaryCollection(results)
+	}
+	poller.RecentlyClosedPullRequests = NewPullRequestSummaryCollection([]PullRequestSummary{})
+	poller.LastPolled = clock.Now()
+	poller.snapshot = newSnapshot(1, poller.LastPolled, pullRequests)
+	return poller, nil
 }
 
-// Poll queries the Github API every `pauseInterval`, and updates the calling
-// code via the `PollerNotificationChannels`. Polling can be stopped via the
-// context provided when calling `NewPoller`.
-func (poller *Poller) Poll(notificationChannels *PollerNotificationChannels, pauseInterval time.Duration) {
+// Poll queries the Github API every `pauseInterval` - or upon a `Refresh` - and
+// publishes a `Notification` to every subscriber as each poll begins and ends.
+// Polling can be stopped via the context provided when calling `NewPoller`. A
+// failed Poll is reported via the Notification's `Err`, and then retried with
+// an exponential backoff; if Github reports that the rate limit has been
+// exhausted, then the Poller will wait until the rate limit resets.
+func (poller *Poller) Poll(pauseInterval time.Duration) {
+	failures := 0
+	next := poller.clock.After(pauseInterval)
 	for {
 		select {
-		case <-time.After(pauseInterval):
-			assigned, created := poller.pullRequests(ASSIGNED_FILTER, CREATED_FILTER)
-			notificationChannels.LatestPollTimestamp <- time.Now()
-
-			poller.Lock()
-			haveUpdatedAssignations := poller.AssignedPullRequests.Update(assigned)
-			haveUpdatedCreations := poller.CreatedPullRequests.Update(created)
-
-			if haveUpdatedAssignations || haveUpdatedCreations {
-				notificationChannels.NewDataAvailable <- struct{}{}
+		case <-next:
+		case <-poller.refresh:
+			// A refresh can't circumvent an exhausted rate limit; the scheduled
+			// poll will take place once it has reset.
+			if poller.RateLimit().Exhausted(poller.clock.Now()) {
+				continue
 			}
-			poller.Unlock()
 		case <-poller.ctx.Done():
 			return
 		}
+
+		poller.notifier.publish(Notification{PolledAt: poller.clock.Now(), Syncing: true})
+
+		err := poller.poll()
+		if err != nil {
+			failures++
+		} else {
+			failures = 0
+		}
+
+		next = poller.clock.After(poller.nextInterval(err, failures, pauseInterval))
+
+		// Any refresh requested whilst polling is superfluous, as the Pull Requests
+		// have only just been retrieved.
+		select {
+		case <-poller.refresh:
+		default:
+		}
 	}
 }
 
-func (poller *Poller) pullRequests(assignedFilterQuery, createdFilterQuery string) ([]PullRequestSummary, []PullRequestSummary) {
-	// simply retrieve pullRequests. we retrieve two sets, so there's an enclosed function
-	// just to simplify the flow.
-	get := func(collection []PullRequestSummary, filterString string) []PullRequestSummary {
-		issues, _, err := poller.client.Issues.List(poller.ctx, true, &github.IssueListOptions{
-			Filter: filterString,
-		})
-		if err != nil {
-			panic(err)
+// Refresh requests an immediate poll, rather than waiting for the remainder of
+// the interval - which then restarts. It never blocks, and multiple requests
+// are combined; any requested whilst a poll is already in progress - or whilst
+// the rate limit is exhausted - are ignored.
+func (poller *Poller) Refresh() {
+	select {
+	case poller.refresh <- struct{}{}:
+	default:
+	}
+}
+
+// Subscribe returns a channel which receives a `Notification` after each poll,
+// until the context is cancelled - at which point the channel is closed. Each
+// subscriber has its own buffer, so a slow subscriber never blocks the Poller
+// or other subscribers; instead, the `SubscribeOptions` determine what happens
+// once its buffer is full. Subscribers should be added before calling `Poll`,
+// else they may miss earlier Notifications.
+func (poller *Poller) Subscribe(ctx context.Context, options *SubscribeOptions) <-chan Notification {
+	return poller.notifier.subscribe(ctx, options)
+}
+
+// RateLimit returns the most recent rate limit status reported by Github; this
+// will be empty if the `PullRequestSource` doesn't implement `RateLimitReporter`.
+func (poller *Poller) RateLimit() RateLimit {
+	if reporter, isReporter := poller.source.(RateLimitReporter); isReporter {
+		return reporter.RateLimit()
+	}
+
+	return RateLimit{}
+}
+
+// CacheStats returns the statistics for any HTTP cache used by the source; this
+// will be empty if the `PullRequestSource` doesn't implement `CacheStatsReporter`.
+func (poller *Poller) CacheStats() CacheStats {
+	if reporter, isReporter := poller.source.(CacheStatsReporter); isReporter {
+		return reporter.CacheStats()
+	}
+
+	return CacheStats{}
+}
+
+func (poller *Poller) nextInterval(err error, failures int, pauseInterval time.Duration) time.Duration {
+	// Double the interval for each consecutive failure, up until the maximum
+	// backoff interval - this cap doesn't apply to the regular interval though.
+	interval := pauseInterval
+	for attempt := 0; attempt < failures && interval < MAX_BACKOFF_INTERVAL; attempt++ {
+		interval *= 2
+	}
+
+	if failures > 0 && interval > MAX_BACKOFF_INTERVAL && pauseInterval < MAX_BACKOFF_INTERVAL {
+		interval = MAX_BACKOFF_INTERVAL
+	}
+
+	// Irrespective of any backoff, there's no point polling again until the
+	// rate limit has been reset.
+	now := poller.clock.Now()
+	untilReset := time.Duration(0)
+
+	var rateErr *RateLimitError
+	if errors.As(err, &rateErr) {
+		if rateErr.RetryAfter > 0 {
+			untilReset = rateErr.RetryAfter
+		} else {
+			untilReset = rateErr.Reset.Sub(now) + RATE_LIMIT_RESET_MARGIN
 		}
+	} else if rate := poller.RateLimit(); rate.Exhausted(now) {
+		untilReset = rate.Reset.Sub(now) + RATE_LIMIT_RESET_MARGIN
+	}
+
+	if untilReset > interval {
+		interval = untilReset
+	}
 
-		for _, issue := range issues {
-			if issue.IsPullRequest() {
-				owner := issue.Repository.GetOwner().GetLogin()
-				repo := issue.Repository.GetName()
+	return interval
+}
+
+// unresolvedPullRequest is a Pull Request which has been removed from all of the
+// Poller's collections, but which the source failed to resolve.
+type unresolvedPullRequest struct {
+	pr        PullRequestSummary
+	removedAt time.Time
+}
+
+// recentlyClosed determines which Pull Requests have been closed - or merged -
+// since the previous poll, and returns them along with any previously closed
+// Pull Requests still within the `ClosedWindow`. If the source fails to resolve
+// a Pull Request then it's retried upon each poll - until it would no longer be
+// within the `ClosedWindow`; whereas if the source doesn't implement
+// `ClosedResolver`, then it's assumed to have been closed just now.
+func (poller *Poller) recentlyClosed(previous, current *PullRequestSets, now time.Time) []PullRequestSummary {
+	open := make(map[string]struct{})
+	for _, pr := range current.all() {
+		open[pr.Key()] = struct{}{}
+	}
+
+	// Pull Requests which have been re-opened are no longer closed.
+	closed := make([]PullRequestSummary, 0)
+	for _, pr := range previous.RecentlyClosed {
+		if _, isOpen := open[pr.Key()]; !isOpen && now.Sub(pr.ClosedAt) < poller.ClosedWindow {
+			closed = append(closed, pr)
+		}
+	}
 
-				if pullRequest, _, err := poller.client.PullRequests.Get(
-					poller.ctx, owner, repo, issue.GetNumber(),
-				); err == nil {
-					collection = append(collection, NewPullRequestFromAPI(issue, pullRequest))
-				}
+	// Pull Requests which previously failed to resolve are retried, along with
+	// those removed since the previous poll.
+	removed := make([]unresolvedPullRequest, 0)
+	for _, unresolved := range poller.unresolved {
+		if _, isOpen := open[unresolved.pr.Key()]; !isOpen && now.Sub(unresolved.removedAt) < poller.ClosedWindow {
+			removed = append(removed, unresolved)
+		}
+	}
+
+	for _, pr := range NewDiff(previous.all(), current.all()).Removed {
+		removed = append(removed, unresolvedPullRequest{pr: pr, removedAt: now})
+	}
+
+	resolver, isResolver := poller.source.(ClosedResolver)
+	poller.unresolved = make([]unresolvedPullRequest, 0)
+	for _, unresolved := range removed {
+		resolved := unresolved.pr
+		if isResolver {
+			// A failed resolution doesn't imply the Pull Request was closed.
+			var err error
+			if resolved, err = resolver.ResolveClosed(poller.ctx, unresolved.pr); err != nil {
+				poller.unresolved = append(poller.unresolved, unresolved)
+				continue
 			}
+		} else {
+			resolved.Status = STATUS_CLOSED
+			resolved.ClosedAt = now
+		}
+
+		// Newly closed Pull Requests are always retained until the next poll,
+		// so that they're reported irrespective of when they were closed.
+		if resolved.Status != STATUS_OPEN {
+			closed = append(closed, resolved)
+		}
+	}
+
+	return closed
+}
+
+// poll retrieves the latest Pull Requests, updates each collection, and then
+// publishes the outcome.
+func (poller *Poller) poll() error {
+	pullRequests, err := poller.pullRequests()
+	if err != nil {
+		poller.notifier.publish(Notification{PolledAt: poller.clock.Now(), Err: err})
+		return err
+	}
+
+	notification := Notification{PolledAt: poller.clock.Now()}
+
+	// Only `Poll` replaces the Snapshot, so the previous Snapshot can be used
+	// whilst resolving any closed Pull Requests - without the lock.
+	previous := poller.Snapshot().sets()
+	pullRequests.RecentlyClosed = poller.recentlyClosed(previous, pullRequests, notification.PolledAt)
+
+	poller.Lock()
+	poller.LastPolled = notification.PolledAt
+	changes := &PollChanges{
+		Assigned:        poller.AssignedPullRequests.Update(pullRequests.Assigned),
+		Created:         poller.CreatedPullRequests.Update(pullRequests.Created),
+		ReviewRequested: poller.ReviewRequestedPullRequests.Update(pullRequests.ReviewRequested),
+		Searches:        make([]Diff, len(pullRequests.Searches)),
+	}
+
+	for idx, results := range pullRequests.Searches {
+		changes.Searches[idx] = poller.SearchPullRequests[idx].Update(results)
+	}
+	changes.RecentlyClosed = poller.RecentlyClosedPullRequests.Update(pullRequests.RecentlyClosed)
+
+	poller.snapshot = newSnapshot(poller.snapshot.Sequence+1, notification.PolledAt, pullRequests)
+	notification.Snapshot = poller.snapshot
+	poller.Unlock()
+
+	if !changes.Empty() {
+		notification.Changes = changes
+	}
+
+	// Publishing never blocks, but is still done without holding the lock;
+	// subscribers are likely to acquire it upon receiving the Notification.
+	notification.Events = NewEvents(previous, pullRequests, notification.PolledAt)
+	poller.notifier.publish(notification)
+	return nil
+}
+
+func (poller *Poller) pullRequests() (*PullRequestSets, error) {
+	pullRequests, err := poller.source.PullRequests(poller.ctx)
+	if err != nil {
+		return nil, fmt.Errorf("unable to retrieve pull requests: %w", err)
+	}
+
+	// Take a copy, rather than modifying the sets returned by the source.
+	sets := *pullRequests
+	sets.Searches = make([][]PullRequestSummary, len(poller.searches))
+	for idx, search := range poller.searches {
+		results, err := poller.source.Search(poller.ctx, search.SearchQuery())
+		if err != nil {
+			return nil, fmt.Errorf("unable to retrieve pull requests for search '%s': %w", search.Name, err)
 		}
 
-		return collection
+		sets.Searches[idx] = results
 	}
 
-	return get(make([]PullRequestSummary, 0), assignedFilterQuery),
-		get(make([]PullRequestSummary, 0), createdFilterQuery)
+	return &sets, nil
 }
@@ -0,0 +1,102 @@
+package github
+
+import "time"
+
+// Account is an authenticated Github account - potentially on a Github
+// Enterprise Server - along with the Poller monitoring it.
+type Account struct {
+	// Name used to identify the account; defaults to the username
+	Name string
+	// Host of the Github instance the account belongs to
+	Host string
+	// Poller for the account; all Pollers in a group are expected to be
+	// configured with the same `SavedSearch` queries.
+	Poller *Poller
+}
+
+// PollerGroup runs the Pollers for multiple Accounts concurrently, and merges
+// their collections; allowing a single TUI to monitor - for example - both a
+// personal github.com account and a work GHES account.
+type PollerGroup struct {
+	Accounts []*Account
+}
+
+// NewPollerGroup returns a `PollerGroup` for the provided Accounts; any Account
+// without a Name is named after the Poller's Username.
+func NewPollerGroup(accounts ...*Account) *PollerGroup {
+	for _, account := range accounts {
+		if account.Name == "" {
+			account.Name = account.Poller.Username
+		}
+	}
+
+	return &PollerGroup{Accounts: accounts}
+}
+
+// Poll starts polling every Account concurrently; Notifications are received by
+// subscribing to each Account's Poller. Polling is stopped via the contexts
+// provided when creating each Poller.
+func (group *PollerGroup) Poll(pauseInterval time.Duration) {
+	for _, account := range group.Accounts {
+		go account.Poller.Poll(pauseInterval)
+	}
+}
+
+// Refresh requests an immediate poll of every Account; see `Poller.Refresh`.
+func (group *PollerGroup) Refresh() {
+	for _, account := range group.Accounts {
+		account.Poller.Refresh()
+	}
+}
+
+// Snapshot merges the most recent Snapshot of every Account, tagging each Pull
+// Request with the Account it was retrieved via. The merged Sequence is the sum
+// of each Account's Sequence - so it still increases with every poll - and the
+// FetchedAt is that of the most recent Account.
+func (group *PollerGroup) Snapshot() *Snapshot {
+	merged := &Snapshot{
+		Assigned:        make([]PullRequestSummary, 0),
+		Created:         make([]PullRequestSummary, 0),
+		ReviewRequested: make([]PullRequestSummary, 0),
+		RecentlyClosed:  make([]PullRequestSummary, 0),
+	}
+
+	for _, account := range group.Accounts {
+		snapshot := account.Poller.Snapshot()
+		merged.Sequence += snapshot.Sequence
+		if snapshot.FetchedAt.After(merged.FetchedAt) {
+			merged.FetchedAt = snapshot.FetchedAt
+		}
+
+		merged.Assigned = account.tag(merged.Assigned, snapshot.Assigned)
+		merged.Created = account.tag(merged.Created, snapshot.Created)
+		merged.ReviewRequested = account.tag(merged.ReviewRequested, snapshot.ReviewRequested)
+		merged.RecentlyClosed = account.tag(merged.RecentlyClosed, snapshot.RecentlyClosed)
+
+		if merged.Searches == nil {
+			merged.Searches = make([][]PullRequestSummary, len(snapshot.Searches))
+			for idx := range merged.Searches {
+				merged.Searches[idx] = make([]PullRequestSummary, 0)
+			}
+		}
+
+		for idx, results := range snapshot.Searches {
+			if idx < len(merged.Searches) {
+				merged.Searches[idx] = account.tag(merged.Searches[idx], results)
+			}
+		}
+	}
+
+	return merged
+}
+
+// tag appends copies of the Pull Requests to `merged`, tagged with the Account.
+func (account *Account) tag(merged, pullRequests []PullRequestSummary) []PullRequestSummary {
+	for _, pullRequest := range pullRequests {
+		pullRequest.Account = account.Name
+		pullRequest.Host = account.Host
+		merged = append(merged, pullRequest)
+	}
+
+	return merged
+}
@@ -0,0 +1,538 @@
+package github
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"reflect"
+	"runtime"
+	"sync"
+	"testing"
+	"time"
+)
+
+// rateLimitedSource is a `FakeSource` which also reports a rate limit status.
+type rateLimitedSource struct {
+	*FakeSource
+	mutex sync.Mutex
+	rate  RateLimit
+}
+
+func (source *rateLimitedSource) RateLimit() RateLimit {
+	source.mutex.Lock()
+	defer source.mutex.Unlock()
+
+	return source.rate
+}
+
+func (source *rateLimitedSource) SetRateLimit(rate RateLimit) {
+	source.mutex.Lock()
+	defer source.mutex.Unlock()
+
+	source.rate = rate
+}
+
+// pollOutcome is the expected outcome of a single poll.
+type pollOutcome struct {
+	// Pull Requests returned by the source
+	sets *PullRequestSets
+	// Type of each Event, in order
+	events []string
+	// Keys of the recently closed Pull Requests
+	recentlyClosed []string
+}
+
+// outcomes subscribes to the Poller, starts polling, and returns the first
+// `count` Notifications describing the outcome of a poll.
+func outcomes(t *testing.T, poller *Poller, count int) []Notification {
+	t.Helper()
+
+	// The fake clock fires immediately, so the Poller polls continuously; as
+	// notifications are dropped - rather than coalesced - once the buffer is
+	// full, the first are always received intact.
+	notifications := poller.Subscribe(poller.ctx, &SubscribeOptions{Buffer: 4 * count, Policy: POLICY_DROP})
+	go poller.Poll(time.Minute)
+
+	received := make([]Notification, 0, count)
+	timeout := time.After(5 * time.Second)
+	for len(received) < count {
+		select {
+		case notification := <-notifications:
+			if !notification.Syncing {
+				received = append(received, notification)
+			}
+		case <-timeout:
+			t.Fatalf("timed out waiting for poll %d", len(received)+1)
+		}
+	}
+
+	return received
+}
+
+func eventTypes(events []Event) []string {
+	types := make([]string, len(events))
+	for idx, event := range events {
+		types[idx] = event.Type
+	}
+
+	return types
+}
+
+func TestPoll(t *testing.T) {
+	a, b := fakePullRequest(1), fakePullRequest(2)
+	mergedA := a
+	mergedA.Status = STATUS_MERGED
+	failingA := a
+	failingA.CIState = CI_FAILURE
+	draftA := a
+	draftA.Draft = true
+
+	tests := []struct {
+		name         string
+		initial      *PullRequestSets
+		resolved     []PullRequestSummary
+		closedWindow time.Duration
+		polls        []pollOutcome
+	}{
+		{
+			name:    "unchanged",
+			initial: &PullRequestSets{Assigned: []PullRequestSummary{a}},
+			polls: []pollOutcome{
+				{sets: &PullRequestSets{Assigned: []PullRequestSummary{a}}, events: []string{}},
+			},
+		},
+		{
+			name:    "opened",
+			initial: &PullRequestSets{Assigned: []PullRequestSummary{a}},
+			polls: []pollOutcome{
+				{sets: &PullRequestSets{Assigned: []PullRequestSummary{a, b}}, events: []string{EVENT_OPENED}},
+			},
+		},
+		{
+			name:    "review requested",
+			initial: &PullRequestSets{},
+			polls: []pollOutcome{
+				{sets: &PullRequestSets{ReviewRequested: []PullRequestSummary{a}}, events: []string{EVENT_OPENED, EVENT_REVIEW_REQUESTED}},
+			},
+		},
+		{
+			name:    "modified",
+			initial: &PullRequestSets{Assigned: []PullRequestSummary{a}},
+			polls: []pollOutcome{
+				{sets: &PullRequestSets{Assigned: []PullRequestSummary{failingA}}, events: []string{EVENT_CI_FAILED}},
+				{sets: &PullRequestSets{Assigned: []PullRequestSummary{draftA}}, events: []string{EVENT_CONVERTED_TO_DRAFT}},
+			},
+		},
+		{
+			name:     "merged",
+			initial:  &PullRequestSets{Assigned: []PullRequestSummary{a, b}},
+			resolved: []PullRequestSummary{mergedA},
+			polls: []pollOutcome{
+				{sets: &PullRequestSets{Assigned: []PullRequestSummary{b}}, events: []string{EVENT_MERGED}, recentlyClosed: []string{a.Key()}},
+			},
+		},
+		{
+			name:    "closed, and retained",
+			initial: &PullRequestSets{Assigned: []PullRequestSummary{a, b}},
+			polls: []pollOutcome{
+				{sets: &PullRequestSets{Assigned: []PullRequestSummary{b}}, events: []string{EVENT_CLOSED}, recentlyClosed: []string{a.Key()}},
+				{sets: &PullRequestSets{Assigned: []PullRequestSummary{b}}, events: []string{}, recentlyClosed: []string{a.Key()}},
+			},
+		},
+		{
+			name:         "closed, and then outside the window",
+			initial:      &PullRequestSets{Assigned: []PullRequestSummary{a}},
+			closedWindow: 90 * time.Second,
+			polls: []pollOutcome{
+				{sets: &PullRequestSets{}, events: []string{EVENT_CLOSED}, recentlyClosed: []string{a.Key()}},
+				{sets: &PullRequestSets{}, events: []string{}, recentlyClosed: []string{a.Key()}},
+				{sets: &PullRequestSets{}, events: []string{}},
+			},
+		},
+		{
+			name:    "reopened",
+			initial: &PullRequestSets{Assigned: []PullRequestSummary{a}},
+			polls: []pollOutcome{
+				{sets: &PullRequestSets{}, events: []string{EVENT_CLOSED}, recentlyClosed: []string{a.Key()}},
+				{sets: &PullRequestSets{Assigned: []PullRequestSummary{a}}, events: []string{EVENT_OPENED}},
+			},
+		},
+		{
+			name:     "still open, but no longer assigned",
+			initial:  &PullRequestSets{Assigned: []PullRequestSummary{a}},
+			resolved: []PullRequestSummary{a},
+			polls: []pollOutcome{
+				{sets: &PullRequestSets{}, events: []string{}},
+			},
+		},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			ctx, cancel := context.WithCancel(context.Background())
+			defer cancel()
+
+			sets := []*PullRequestSets{test.initial}
+			for _, poll := range test.polls {
+				sets = append(sets, poll.sets)
+			}
+
+			clock := newFakeClock()
+			source := NewFakeSource("user", sets...)
+			source.SetClock(clock)
+			for _, pr := range test.resolved {
+				source.SetResolved(pr)
+			}
+
+			poller, err := NewPoller(ctx, source, nil, clock)
+			if err != nil {
+				t.Fatal(err)
+			}
+
+			if test.closedWindow > 0 {
+				poller.ClosedWindow = test.closedWindow
+			}
+
+			for idx, notification := range outcomes(t, poller, len(test.polls)) {
+				expected := test.polls[idx]
+				if notification.Err != nil {
+					t.Fatalf("poll %d: unexpected error %v", idx+1, notification.Err)
+				}
+
+				if events := eventTypes(notification.Events); !reflect.DeepEqual(events, expected.events) {
+					t.Errorf("poll %d: expected the events %v, found %v", idx+1, expected.events, events)
+				}
+
+				if closed := keys(notification.Snapshot.RecentlyClosed); len(closed) != len(expected.recentlyClosed) ||
+					(len(closed) > 0 && !reflect.DeepEqual(closed, expected.recentlyClosed)) {
+					t.Errorf("poll %d: expected %v to be recently closed, found %v", idx+1, expected.recentlyClosed, closed)
+				}
+
+				if notification.Snapshot.Sequence != uint64(idx+2) {
+					t.Errorf("poll %d: expected the Sequence %d, found %d", idx+1, idx+2, notification.Snapshot.Sequence)
+				}
+
+				if len(expected.events) > 0 && notification.Changes == nil {
+					t.Errorf("poll %d: expected the changes to be included", idx+1)
+				}
+			}
+		})
+	}
+}
+
+func TestPollBacksOffOnFailure(t *testing.T) {
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+
+	clock := newFakeClock()
+	source := NewFakeSource("user", &PullRequestSets{})
+	poller, err := NewPoller(ctx, source, nil, clock)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	failure := errors.New("unavailable")
+	source.SetError(failure)
+	for idx, notification := range outcomes(t, poller, 4) {
+		if !errors.Is(notification.Err, failure) || notification.Snapshot != nil {
+			t.Fatalf("poll %d: expected the failure to be reported, found %+v", idx+1, notification)
+		}
+	}
+
+	// The initial interval, followed by a doubling upon each failure.
+	waits := clock.Waits()[:4]
+	if expected := []time.Duration{time.Minute, 2 * time.Minute, 4 * time.Minute, 8 * time.Minute}; !reflect.DeepEqual(waits, expected) {
+		t.Fatalf("expected the intervals %v, found %v", expected, waits)
+	}
+}
+
+func TestPollWaitsForRateLimitReset(t *testing.T) {
+	// The first poll takes place after the initial interval.
+	polledAt := newFakeClock().Now().Add(time.Minute)
+
+	tests := []struct {
+		name string
+		err  error
+		rate RateLimit
+		// Interval before the subsequent poll
+		wait time.Duration
+	}{
+		{
+			name: "primary rate limit",
+			err:  &RateLimitError{Reset: polledAt.Add(30 * time.Minute)},
+			wait: 30*time.Minute + RATE_LIMIT_RESET_MARGIN,
+		},
+		{
+			name: "secondary rate limit",
+			err:  &RateLimitError{RetryAfter: 10 * time.Minute},
+			wait: 10 * time.Minute,
+		},
+		{
+			name: "wrapped rate limit",
+			err:  fmt.Errorf("graphql: %w", &RateLimitError{Reset: polledAt.Add(30 * time.Minute)}),
+			wait: 30*time.Minute + RATE_LIMIT_RESET_MARGIN,
+		},
+		{
+			name: "reset before the backoff interval",
+			err:  &RateLimitError{Reset: polledAt.Add(30 * time.Second)},
+			wait: 2 * time.Minute,
+		},
+		{
+			name: "exhausted by a successful poll",
+			rate: RateLimit{Limit: 5000, Remaining: 0, Reset: polledAt.Add(30 * time.Minute)},
+			wait: 30*time.Minute + RATE_LIMIT_RESET_MARGIN,
+		},
+		{
+			name: "remaining",
+			rate: RateLimit{Limit: 5000, Remaining: 10, Reset: polledAt.Add(30 * time.Minute)},
+			wait: time.Minute,
+		},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			ctx, cancel := context.WithCancel(context.Background())
+			defer cancel()
+
+			clock := newFakeClock()
+			source := &rateLimitedSource{FakeSource: NewFakeSource("user", &PullRequestSets{})}
+			poller, err := NewPoller(ctx, source, nil, clock)
+			if err != nil {
+				t.Fatal(err)
+			}
+
+			source.SetError(test.err)
+			source.SetRateLimit(test.rate)
+			notification := outcomes(t, poller, 2)[0]
+			if !errors.Is(notification.Err, test.err) {
+				t.Fatalf("expected the error %v, found %v", test.err, notification.Err)
+			}
+
+			if waits := clock.Waits()[:2]; waits[1] != test.wait {
+				t.Fatalf("expected to wait %s after the poll, found %v", test.wait, waits)
+			}
+		})
+	}
+}
+
+func TestPollRetriesUnresolvedPullRequests(t *testing.T) {
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+
+	a, b := fakePullRequest(1), fakePullRequest(2)
+	clock := newFakeClock()
+	merged := a
+	merged.Status = STATUS_MERGED
+	merged.ClosedAt = clock.Now()
+	source := NewFakeSource("user",
+		&PullRequestSets{Assigned: []PullRequestSummary{a, b}},
+		&PullRequestSets{Assigned: []PullRequestSummary{b}},
+	)
+	source.SetResolved(merged)
+
+	poller, err := NewPoller(ctx, source, nil, clock)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	notifications := poller.Subscribe(ctx, nil)
+	poll := func() Notification {
+		clock.Advance(time.Minute)
+		if err := poller.poll(); err != nil {
+			t.Fatal(err)
+		}
+
+		return <-notifications
+	}
+
+	// Regression: a failed resolution was treated as the Pull Request having
+	// been closed, even if it had been merged - or was still open.
+	source.SetResolveError(errors.New("unavailable"))
+	for attempt := 1; attempt <= 2; attempt++ {
+		notification := poll()
+		if len(notification.Events) != 0 || len(notification.Snapshot.RecentlyClosed) != 0 {
+			t.Fatalf("attempt %d: expected the Pull Request to remain unresolved, found %v closed and the events %v",
+				attempt, keys(notification.Snapshot.RecentlyClosed), eventTypes(notification.Events))
+		}
+	}
+
+	source.SetResolveError(nil)
+	notification := poll()
+	if events := eventTypes(notification.Events); !reflect.DeepEqual(events, []string{EVENT_MERGED}) {
+		t.Fatalf("expected the Pull Request to be merged once resolved, found the events %v", events)
+	}
+
+	if closed := keys(notification.Snapshot.RecentlyClosed); !reflect.DeepEqual(closed, []string{a.Key()}) {
+		t.Fatalf("expected the Pull Request to be recently closed, found %v", closed)
+	}
+
+	// Having been resolved, it isn't resolved - or reported - again.
+	if notification := poll(); len(notification.Events) != 0 || len(notification.Snapshot.RecentlyClosed) != 1 {
+		t.Fatalf("expected the Pull Request to be retained without any events, found %v", eventTypes(notification.Events))
+	}
+}
+
+// blockingSource is a `FakeSource` whose first call to `PullRequests` - once
+// `started` is set - signals `started`, and then waits for `release`.
+type blockingSource struct {
+	*FakeSource
+	once    sync.Once
+	started chan struct{}
+	release chan struct{}
+}
+
+func (source *blockingSource) PullRequests(ctx context.Context) (*PullRequestSets, error) {
+	if source.started != nil {
+		source.once.Do(func() {
+			close(source.started)
+			<-source.release
+		})
+	}
+
+	return source.FakeSource.PullRequests(ctx)
+}
+
+// refreshedPoller polls - via a paused clock, so only upon a refresh - until it's
+// stopped; at which point it verifies the number of polls, and that no refresh
+// remains pending.
+type refreshedPoller struct {
+	*Poller
+	clock         *fakeClock
+	source        interface{ Calls() int }
+	notifications <-chan Notification
+	cancel        context.CancelFunc
+	done          chan struct{}
+}
+
+func newRefreshedPoller(t *testing.T, source PullRequestSource, calls interface{ Calls() int }) *refreshedPoller {
+	t.Helper()
+
+	ctx, cancel := context.WithCancel(context.Background())
+	t.Cleanup(cancel)
+
+	clock := newPausedClock()
+	poller, err := NewPoller(ctx, source, nil, clock)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	return &refreshedPoller{
+		Poller:        poller,
+		clock:         clock,
+		source:        calls,
+		notifications: poller.Subscribe(ctx, &SubscribeOptions{Buffer: 16, Policy: POLICY_DROP}),
+		cancel:        cancel,
+		done:          make(chan struct{}),
+	}
+}
+
+func (poller *refreshedPoller) start() {
+	go func() {
+		poller.Poll(time.Hour)
+		close(poller.done)
+	}()
+}
+
+// result waits for the outcome of the next poll.
+func (poller *refreshedPoller) result(t *testing.T) Notification {
+	t.Helper()
+
+	timeout := time.After(5 * time.Second)
+	for {
+		select {
+		case notification := <-poller.notifications:
+			if !notification.Syncing {
+				return notification
+			}
+		case <-timeout:
+			t.Fatal("timed out waiting for a poll")
+		}
+	}
+}
+
+// stop stops polling, and verifies that `polls` were made - excluding the
+// initial poll made by `NewPoller`.
+func (poller *refreshedPoller) stop(t *testing.T, polls int) {
+	t.Helper()
+
+	poller.cancel()
+	<-poller.done
+
+	if calls := poller.source.Calls() - 1; calls != polls {
+		t.Errorf("expected %d polls, found %d", polls, calls)
+	}
+
+	// A pending refresh would otherwise have been dropped by the cancellation.
+	if len(poller.refresh) > 0 {
+		t.Error("expected no refresh to remain pending")
+	}
+}
+
+func TestPollRefresh(t *testing.T) {
+	t.Run("polls immediately", func(t *testing.T) {
+		source := NewFakeSource("user", &PullRequestSets{})
+		poller := newRefreshedPoller(t, source, source)
+		poller.start()
+
+		poller.Refresh()
+		if notification := poller.result(t); notification.Err != nil || notification.Snapshot.Sequence != 2 {
+			t.Fatalf("expected the refresh to poll, found %+v", notification)
+		}
+
+		poller.stop(t, 1)
+
+		// The interval restarts following the refresh.
+		if waits := poller.clock.Waits(); !reflect.DeepEqual(waits, []time.Duration{time.Hour, time.Hour}) {
+			t.Errorf("expected the interval to restart, found the waits %v", waits)
+		}
+	})
+
+	t.Run("combines repeated requests", func(t *testing.T) {
+		source := NewFakeSource("user", &PullRequestSets{})
+		poller := newRefreshedPoller(t, source, source)
+		for idx := 0; idx < 3; idx++ {
+			poller.Refresh()
+		}
+
+		poller.start()
+		poller.result(t)
+		poller.stop(t, 1)
+	})
+
+	t.Run("drops requests made whilst polling", func(t *testing.T) {
+		source := &blockingSource{FakeSource: NewFakeSource("user", &PullRequestSets{})}
+		poller := newRefreshedPoller(t, source, source)
+		source.started, source.release = make(chan struct{}), make(chan struct{})
+		poller.start()
+
+		poller.Refresh()
+		<-source.started
+		poller.Refresh()
+		close(source.release)
+
+		poller.result(t)
+		poller.stop(t, 1)
+	})
+
+	t.Run("ignores requests whilst the rate limit is exhausted", func(t *testing.T) {
+		source := &rateLimitedSource{FakeSource: NewFakeSource("user", &PullRequestSets{})}
+		poller := newRefreshedPoller(t, source, source)
+		source.SetRateLimit(RateLimit{Limit: 5000, Remaining: 0, Reset: poller.clock.Now().Add(30 * time.Minute)})
+		poller.start()
+
+		poller.Refresh()
+		for timeout := time.After(5 * time.Second); len(poller.refresh) > 0; {
+			select {
+			case <-timeout:
+				t.Fatal("timed out waiting for the refresh to be received")
+			default:
+				runtime.Gosched()
+			}
+		}
+
+		poller.stop(t, 0)
+		for notification := range poller.notifications {
+			t.Errorf("expected no polls, found %+v", notification)
+		}
+	})
+}
@@ -2,7 +2,9 @@ package github
 
 import (
 	"fmt"
+	"sort"
 	"strconv"
+	"strings"
 	"time"
 
 	"github.com/google/go-github/v32/github"
@@ -38,11 +40,17 @@ func (collection *PullRequestSummaryCollection) VersionKeys() []string {
 	return versionKeys
 }
 
-// Update accepts a new slice of `PullRequestSummary` structs, and returns a boolean
-// indicating whether the new entities differ from the existing ones.
-func (collection *PullRequestSummaryCollection) Update(latestItems []PullRequestSummary) bool {
+// Update accepts a new slice of `PullRequestSummary` structs, and returns a Diff
+// describing how the new entities differ from the existing ones; the Diff is
+// empty if there are no changes.
+func (collection *PullRequestSummaryCollection) Update(latestItems []PullRequestSummary) Diff {
+	previousItems := collection.Items
 	collection.Items = latestItems
-	return collection.dirtyChecker.HasChanged(collection)
+	if !collection.dirtyChecker.HasChanged(collection) {
+		return Diff{}
+	}
+
+	return NewDiff(previousItems, latestItems)
 }
 
 // PullRequestSummary is a simplified PullRequest object which only contains a
@@ -54,36 +62,87 @@ type PullRequestSummary struct {
 	Repository    string
 	ID            string
 	ReviewerCount int
+	Review        ReviewSummary
 	Status        string
+	CIState       string
+	Comments      int
 	OpenedAt      time.Time
-	URL           string
+	// Only populated for Pull Requests in the Poller's recently closed collection
+	ClosedAt time.Time
+	URL      string
+	// Account - and Github host - which the Pull Request was retrieved via; only
+	// populated when merging the collections of multiple accounts.
+	Account string
+	Host    string
 }
 
 // NewPullRequestFromAPI generates a `PullRequestSummary` from the records returned
 // from the Github API - both a `github.Issue` and a `github.PullRequest`. A quirk
 // of the API is that a Pull Request *is an Issue*, but it has additional fields
 // that need to be retrieved via a secondary API cal - for this reason both params
-// are required.
-func NewPullRequestFromAPI(issue *github.Issue, pr *github.PullRequest) PullRequestSummary {
+// are required. The submitted reviews are also required to determine the state
+// of any reviews.
+func NewPullRequestFromAPI(issue *github.Issue, pr *github.PullRequest, reviews []*github.PullRequestReview) PullRequestSummary {
+	_, repo := issueRepository(issue)
+	review := NewReviewSummaryFromAPI(pr, reviews)
 	return PullRequestSummary{
-		Draft:      pr.GetDraft(),
-		Author:     issue.GetUser().GetLogin(),
-		Title:      issue.GetTitle(),
-		Repository: issue.Repository.GetName(),
-		ID:         strconv.Itoa(issue.GetNumber()),
-		Status:     issue.GetState(),
-		OpenedAt:   issue.GetCreatedAt(),
-		URL:        pr.GetHTMLURL(),
+		Draft:         pr.GetDraft(),
+		Author:        issue.GetUser().GetLogin(),
+		Title:         issue.GetTitle(),
+		Repository:    repo,
+		ID:            strconv.Itoa(issue.GetNumber()),
+		ReviewerCount: review.ReviewerCount(),
+		Review:        review,
+		Status:        issue.GetState(),
+		Comments:      issue.GetComments(),
+		OpenedAt:      issue.GetCreatedAt(),
+		URL:           pr.GetHTMLURL(),
 	}
 }
 
+// Key uniquely identifies the Pull Request by its repository and number; the
+// URL is preferred as it also includes the owner of the repository and the host.
+func (pr PullRequestSummary) Key() string {
+	if pr.URL != "" {
+		return pr.URL
+	}
+
+	return pr.Repository + "#" + pr.ID
+}
+
 // We care about whether it's the same repository, whether it's status has changed
-// whether it remains a draft. Key = [repository]:[prNum]:[status]:[draft]
+// whether it remains a draft, and whether the review decision, any individual
+// review, CI state, or number of comments have changed.
+// Key = [repository]:[prNum]:[status]:[draft]:[reviewers]:[review]:[reviews]:[ci]:[comments]
 func (pr PullRequestSummary) versionKey() string {
 	draftStr := "N"
 	if pr.Draft {
 		draftStr = "Y"
 	}
 
-	return fmt.Sprintf("%s:%s:%s:%s", pr.Repository, pr.ID, pr.Status, draftStr)
+	reviews := make([]string, 0, len(pr.Review.Reviews))
+	for reviewer, state := range pr.Review.Reviews {
+		reviews = append(reviews, reviewer+"="+state)
+	}
+	sort.Strings(reviews)
+
+	return fmt.Sprintf("%s:%s:%s:%s:%d:%s:%s:%s:%d", pr.Repository, pr.ID, pr.Status, draftStr,
+		pr.ReviewerCount, pr.Review.Decision, strings.Join(reviews, ","), pr.CIState, pr.Comments)
+}
+
+// issueRepository returns the owner and name of the repository associated with
+// an Issue; Issues returned via the Search API don't include the repository
+// object though, so it's parsed from the repository URL instead.
+func issueRepository(issue *github.Issue) (string, string) {
+	if issue.Repository != nil {
+		return issue.Repository.GetOwner().GetLogin(), issue.Repository.GetName()
+	}
+
+	// i.e https://api.github.com/repos/[owner]/[repo]
+	segments := strings.Split(strings.TrimSuffix(issue.GetRepositoryURL(), "/"), "/")
+	if len(segments) < 2 {
+		return "", ""
+	}
+
+	return segments[len(segments)-2], segments[len(segments)-1]
 }
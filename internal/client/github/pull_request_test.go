@@ -0,0 +1,128 @@
+package github
+
+import (
+	"fmt"
+	"reflect"
+	"testing"
+)
+
+// fakePullRequest returns an open Pull Request distinguished by its number.
+func fakePullRequest(number int) PullRequestSummary {
+	return PullRequestSummary{
+		Repository: "o/r",
+		ID:         fmt.Sprint(number),
+		Title:      fmt.Sprintf("Pull Request %d", number),
+		Status:     STATUS_OPEN,
+		URL:        fmt.Sprintf("https://github.com/o/r/pull/%d", number),
+	}
+}
+
+// keys returns the `Key` of each Pull Request, in order.
+func keys(pullRequests []PullRequestSummary) []string {
+	keys := make([]string, len(pullRequests))
+	for idx, pr := range pullRequests {
+		keys[idx] = pr.Key()
+	}
+
+	return keys
+}
+
+func TestPullRequestSummaryCollectionUpdate(t *testing.T) {
+	a, b, c := fakePullRequest(1), fakePullRequest(2), fakePullRequest(3)
+	failing := b
+	failing.CIState = CI_FAILURE
+	retitled := b
+	retitled.Title = "Retitled"
+
+	tests := []struct {
+		name     string
+		previous []PullRequestSummary
+		latest   []PullRequestSummary
+		added    []string
+		removed  []string
+		modified map[string][]string
+	}{
+		{
+			name:     "unchanged",
+			previous: []PullRequestSummary{a, b},
+			latest:   []PullRequestSummary{a, b},
+		},
+		{
+			name:     "reordered",
+			previous: []PullRequestSummary{a, b, c},
+			latest:   []PullRequestSummary{c, a, b},
+		},
+		{
+			name:     "added",
+			previous: []PullRequestSummary{a},
+			latest:   []PullRequestSummary{a, b, c},
+			added:    []string{b.Key(), c.Key()},
+		},
+		{
+			name:     "removed",
+			previous: []PullRequestSummary{a, b, c},
+			latest:   []PullRequestSummary{b},
+			removed:  []string{a.Key(), c.Key()},
+		},
+		{
+			name:     "modified",
+			previous: []PullRequestSummary{a, b},
+			latest:   []PullRequestSummary{a, failing},
+			modified: map[string][]string{b.Key(): {FIELD_CI}},
+		},
+		{
+			name:     "untracked field modified",
+			previous: []PullRequestSummary{a, b},
+			latest:   []PullRequestSummary{a, retitled},
+		},
+		{
+			name:     "added, removed and modified",
+			previous: []PullRequestSummary{a, b},
+			latest:   []PullRequestSummary{failing, c},
+			added:    []string{c.Key()},
+			removed:  []string{a.Key()},
+			modified: map[string][]string{b.Key(): {FIELD_CI}},
+		},
+		{
+			name:     "emptied",
+			previous: []PullRequestSummary{a},
+			latest:   []PullRequestSummary{},
+			removed:  []string{a.Key()},
+		},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			collection := NewPullRequestSummaryCollection(test.previous)
+			diff := collection.Update(test.latest)
+
+			if added := keys(diff.Added); len(added) != len(test.added) || (len(added) > 0 && !reflect.DeepEqual(added, test.added)) {
+				t.Errorf("expected %v to be added, found %v", test.added, added)
+			}
+
+			if removed := keys(diff.Removed); len(removed) != len(test.removed) || (len(removed) > 0 && !reflect.DeepEqual(removed, test.removed)) {
+				t.Errorf("expected %v to be removed, found %v", test.removed, removed)
+			}
+
+			modified := make(map[string][]string)
+			for _, change := range diff.Modified {
+				for _, field := range change.Fields {
+					modified[change.Current.Key()] = append(modified[change.Current.Key()], field.Field)
+				}
+			}
+
+			if len(modified) != len(test.modified) || (len(modified) > 0 && !reflect.DeepEqual(modified, test.modified)) {
+				t.Errorf("expected %v to be modified, found %v", test.modified, modified)
+			}
+
+			if !reflect.DeepEqual(collection.Items, test.latest) {
+				t.Errorf("expected the collection to contain the latest items, found %v", collection.Items)
+			}
+
+			// Having been updated, the same items are no longer a change.
+			if diff := collection.Update(test.latest); !diff.Empty() {
+				t.Errorf("expected a repeated update to be empty, found %+v", diff)
+			}
+		})
+	}
+}
@@ -0,0 +1,164 @@
+package github
+
+import (
+	"fmt"
+	"net/http"
+	"strconv"
+	"sync"
+	"time"
+
+	"github.com/google/go-github/v32/github"
+)
+
+const (
+	// MAX_BACKOFF_INTERVAL is the longest the Poller will wait between polls
+	// when backing off after consecutive failures.
+	MAX_BACKOFF_INTERVAL = time.Hour
+	// RATE_LIMIT_RESET_MARGIN is added to the reset time reported by Github, to
+	// allow for any clock skew between Github and the local machine.
+	RATE_LIMIT_RESET_MARGIN = 5 * time.Second
+)
+
+// RateLimit contains the most recent rate limit status reported by Github.
+type RateLimit struct {
+	// The number of requests per hour the client is limited to
+	Limit int
+	// The number of requests remaining in the current window
+	Remaining int
+	// The time at which the current rate limit window resets
+	Reset time.Time
+}
+
+// Known returns whether any rate limit information has been received.
+func (rate RateLimit) Known() bool {
+	return rate.Limit > 0
+}
+
+// Exhausted returns whether the rate limit has been exhausted, with the reset
+// time still in the future.
+func (rate RateLimit) Exhausted(now time.Time) bool {
+	return rate.Known() && rate.Remaining == 0 && rate.Reset.After(now)
+}
+
+// RateLimitReporter is implemented by any `PullRequestSource` which is able to
+// report the rate limit status returned by Github.
+type RateLimitReporter interface {
+	// RateLimit returns the most recently observed rate limit status.
+	RateLimit() RateLimit
+}
+
+// RateLimitError is returned by a `PullRequestSource` when Github refuses a
+// request due to either the primary or the secondary ("abuse") rate limit.
+type RateLimitError struct {
+	// The time at which the primary rate limit resets
+	Reset time.Time
+	// The duration to wait, as requested by a secondary rate limit
+	RetryAfter time.Duration
+	// The underlying error
+	Err error
+}
+
+func (e *RateLimitError) Error() string {
+	if e.RetryAfter > 0 {
+		return fmt.Sprintf("secondary rate limit exceeded; retry after %s", e.RetryAfter)
+	}
+
+	return fmt.Sprintf("rate limit exceeded; resets at %s", e.Reset.Format("15:04:05"))
+}
+
+// Unwrap returns the underlying error.
+func (e *RateLimitError) Unwrap() error {
+	return e.Err
+}
+
+// rateLimitTracker is embedded in a `PullRequestSource` to record the most
+// recent rate limit status; it's safe for concurrent usage.
+type rateLimitTracker struct {
+	mutex sync.Mutex
+	rate  RateLimit
+}
+
+// RateLimit returns the most recently observed rate limit status.
+func (tracker *rateLimitTracker) RateLimit() RateLimit {
+	tracker.mutex.Lock()
+	defer tracker.mutex.Unlock()
+
+	return tracker.rate
+}
+
+func (tracker *rateLimitTracker) update(rate RateLimit) {
+	if !rate.Known() {
+		return
+	}
+
+	tracker.mutex.Lock()
+	defer tracker.mutex.Unlock()
+	tracker.rate = rate
+}
+
+// observeREST records the rate limit from a go-github `Response`, and converts
+// any go-github rate limit errors into a `RateLimitError`.
+func (tracker *rateLimitTracker) observeREST(resp *github.Response, err error) error {
+	if resp != nil {
+		tracker.update(RateLimit{
+			Limit:     resp.Rate.Limit,
+			Remaining: resp.Rate.Remaining,
+			Reset:     resp.Rate.Reset.Time,
+		})
+	}
+
+	switch rateErr := err.(type) {
+	case *github.RateLimitError:
+		return &RateLimitError{Reset: rateErr.Rate.Reset.Time, Err: err}
+	case *github.AbuseRateLimitError:
+		return &RateLimitError{RetryAfter: rateErr.GetRetryAfter(), Err: err}
+	}
+
+	return err
+}
+
+// observeHTTP records the rate limit from the headers of a raw `http.Response`,
+// returning a `RateLimitError` if the response indicates the request has been
+// refused due to a rate limit.
+func (tracker *rateLimitTracker) observeHTTP(resp *http.Response) error {
+	rate := RateLimit{}
+	rate.Limit, _ = strconv.Atoi(resp.Header.Get("X-RateLimit-Limit"))
+	rate.Remaining, _ = strconv.Atoi(resp.Header.Get("X-RateLimit-Remaining"))
+	if reset, err := strconv.ParseInt(resp.Header.Get("X-RateLimit-Reset"), 10, 64); err == nil {
+		rate.Reset = time.Unix(reset, 0)
+	}
+	tracker.update(rate)
+
+	if resp.StatusCode != http.StatusForbidden && resp.StatusCode != http.StatusTooManyRequests {
+		return nil
+	}
+
+	cause := fmt.Errorf("unexpected response status %s", resp.Status)
+	if retryAfter, err := strconv.Atoi(resp.Header.Get("Retry-After")); err == nil {
+		return &RateLimitError{RetryAfter: time.Duration(retryAfter) * time.Second, Err: cause}
+	}
+
+	if rate.Known() && rate.Remaining == 0 {
+		return &RateLimitError{Reset: rate.Reset, Err: cause}
+	}
+
+	return nil
+}
+
+// Clock provides the current time, and timers; it allows the scheduling of the
+// Poller to be controlled in tests.
+type Clock interface {
+	Now() time.Time
+	After(time.Duration) <-chan time.Time
+}
+
+// realClock is the default `Clock`, backed by the `time` package.
+type realClock struct{}
+
+func (realClock) Now() time.Time {
+	return time.Now()
+}
+
+func (realClock) After(d time.Duration) <-chan time.Time {
+	return time.After(d)
+}
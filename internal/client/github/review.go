@@ -0,0 +1,153 @@
+package github
+
+import (
+	"sort"
+	"strings"
+
+	"github.com/google/go-github/v32/github"
+)
+
+const (
+	// REVIEW_APPROVED indicates a reviewer has approved the Pull Request
+	REVIEW_APPROVED = "approved"
+	// REVIEW_CHANGES_REQUESTED indicates a reviewer has requested changes
+	REVIEW_CHANGES_REQUESTED = "changes_requested"
+	// REVIEW_COMMENTED indicates a reviewer has only left comments
+	REVIEW_COMMENTED = "commented"
+	// REVIEW_PENDING indicates that reviews have been requested, but none of
+	// the reviewers have responded yet
+	REVIEW_PENDING = "pending"
+	// REVIEW_NONE indicates that no reviews have been requested or submitted
+	REVIEW_NONE = "none"
+)
+
+// ReviewSummary contains the reviewers associated with a Pull Request, the
+// latest state of each submitted review, and an overall review decision.
+type ReviewSummary struct {
+	// Logins of the users whose review has been requested
+	RequestedReviewers []string
+	// Slugs of the teams whose review has been requested
+	RequestedTeams []string
+	// Latest review state - i.e `REVIEW_APPROVED` - keyed by reviewer login
+	Reviews map[string]string
+	// Overall decision derived from the above; one of the `REVIEW_` constants
+	Decision string
+}
+
+// ReviewerCount returns the number of distinct reviewers: requested users and
+// teams, as well as anyone who has already submitted a review.
+func (summary ReviewSummary) ReviewerCount() int {
+	reviewers := make(map[string]struct{})
+	for _, login := range summary.RequestedReviewers {
+		reviewers[login] = struct{}{}
+	}
+
+	for login := range summary.Reviews {
+		reviewers[login] = struct{}{}
+	}
+
+	return len(reviewers) + len(summary.RequestedTeams)
+}
+
+// NewReviewSummary collects the requested reviewers and teams, and the latest
+// state of each submitted review, before deriving the overall decision.
+func NewReviewSummary(requestedReviewers, requestedTeams []string, reviews map[string]string) ReviewSummary {
+	sort.Strings(requestedReviewers)
+	sort.Strings(requestedTeams)
+
+	summary := ReviewSummary{
+		RequestedReviewers: requestedReviewers,
+		RequestedTeams:     requestedTeams,
+		Reviews:            reviews,
+		Decision:           REVIEW_NONE,
+	}
+
+	states := make(map[string]bool)
+	for _, state := range reviews {
+		states[state] = true
+	}
+
+	// Any requested changes take priority, regardless of approvals.
+	switch {
+	case states[REVIEW_CHANGES_REQUESTED]:
+		summary.Decision = REVIEW_CHANGES_REQUESTED
+	case states[REVIEW_APPROVED]:
+		summary.Decision = REVIEW_APPROVED
+	case states[REVIEW_COMMENTED]:
+		summary.Decision = REVIEW_COMMENTED
+	case len(requestedReviewers)+len(requestedTeams) > 0:
+		summary.Decision = REVIEW_PENDING
+	}
+
+	return summary
+}
+
+// NewReviewSummaryFromAPI generates a `ReviewSummary` from a `github.PullRequest`
+// and the reviews submitted against it. Reviews are expected in chronological
+// order - as returned by the API - so that the latest review per user wins.
+func NewReviewSummaryFromAPI(pr *github.PullRequest, reviews []*github.PullRequestReview) ReviewSummary {
+	requestedReviewers := make([]string, 0, len(pr.RequestedReviewers))
+	for _, user := range pr.RequestedReviewers {
+		requestedReviewers = append(requestedReviewers, user.GetLogin())
+	}
+
+	requestedTeams := make([]string, 0, len(pr.RequestedTeams))
+	for _, team := range pr.RequestedTeams {
+		requestedTeams = append(requestedTeams, team.GetSlug())
+	}
+
+	submitted := make([]submittedReview, len(reviews))
+	for idx, review := range reviews {
+		submitted[idx] = submittedReview{
+			login: review.GetUser().GetLogin(),
+			state: review.GetState(),
+		}
+	}
+
+	return NewReviewSummary(requestedReviewers, requestedTeams, latestReviewStates(submitted))
+}
+
+// submittedReview is the minimal representation of a review, common to both
+// the REST and GraphQL APIs.
+type submittedReview struct {
+	login string
+	state string
+}
+
+func latestReviewStates(reviews []submittedReview) map[string]string {
+	// Determine the latest state per reviewer; reviews are expected to be in
+	// chronological order.
+	latestReviews := make(map[string]string)
+	for _, review := range reviews {
+		if strings.EqualFold(review.state, "DISMISSED") {
+			delete(latestReviews, review.login)
+			continue
+		}
+
+		// A comment shouldn't override an earlier approval or request for
+		// changes; this mirrors how Github presents the review status.
+		state := normaliseReviewState(review.state)
+		if state == "" || (state == REVIEW_COMMENTED && latestReviews[review.login] != "") {
+			continue
+		}
+
+		latestReviews[review.login] = state
+	}
+
+	return latestReviews
+}
+
+func normaliseReviewState(state string) string {
+	// Review states are reported in upper case by both the REST and GraphQL
+	// APIs; pending reviews have no bearing on the decision.
+	switch strings.ToUpper(state) {
+	case "APPROVED":
+		return REVIEW_APPROVED
+	case "CHANGES_REQUESTED":
+		return REVIEW_CHANGES_REQUESTED
+	case "COMMENTED":
+		return REVIEW_COMMENTED
+	}
+
+	return ""
+}
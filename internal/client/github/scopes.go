@@ -0,0 +1,87 @@
+package github
+
+import (
+	"context"
+	"strings"
+
+	"golang.org/x/oauth2"
+)
+
+// SCOPES_HEADER is the header in which Github reports the OAuth scopes granted
+// to a token; it's absent for fine-grained tokens and Github App tokens.
+const SCOPES_HEADER = "X-OAuth-Scopes"
+
+var (
+	// REQUIRED_SCOPES are the OAuth scopes required to retrieve Pull Requests
+	// from private repositories, and review requests made via teams.
+	REQUIRED_SCOPES = []string{"repo", "read:org"}
+	// impliedScopes maps a scope onto those scopes which grant it implicitly.
+	impliedScopes = map[string][]string{
+		"read:org": {"write:org", "admin:org"},
+	}
+)
+
+// ParseScopes parses the comma separated scopes from the `X-OAuth-Scopes` header.
+func ParseScopes(header string) []string {
+	scopes := make([]string, 0)
+	for _, scope := range strings.Split(header, ",") {
+		if scope = strings.TrimSpace(scope); scope != "" {
+			scopes = append(scopes, scope)
+		}
+	}
+
+	return scopes
+}
+
+// MissingScopes returns the `required` scopes which aren't granted - directly
+// or implicitly - by the `granted` scopes.
+func MissingScopes(granted, required []string) []string {
+	grantedSet := make(map[string]struct{})
+	for _, scope := range granted {
+		grantedSet[scope] = struct{}{}
+	}
+
+	missing := make([]string, 0)
+	for _, scope := range required {
+		if _, isGranted := grantedSet[scope]; isGranted {
+			continue
+		}
+
+		isImplied := false
+		for _, implying := range impliedScopes[scope] {
+			if _, isGranted := grantedSet[implying]; isGranted {
+				isImplied = true
+			}
+		}
+
+		if !isImplied {
+			missing = append(missing, scope)
+		}
+	}
+
+	return missing
+}
+
+// Scopes retrieves the authenticated user - via the same `Users.Get` call used
+// by `CurrentUser` - along with the OAuth scopes granted to the token.
+func (source *RESTSource) Scopes(ctx context.Context) (string, []string, error) {
+	currentUser, resp, err := source.client.Users.Get(ctx, "")
+	if err = source.observeREST(resp, err); err != nil {
+		return "", nil, err
+	}
+
+	return currentUser.GetLogin(), ParseScopes(resp.Header.Get(SCOPES_HEADER)), nil
+}
+
+// TokenScopes retrieves the authenticated user, and the OAuth scopes granted to
+// the token, from the Github instance.
+func TokenScopes(ctx context.Context, endpoints Endpoints, token string) (string, []string, error) {
+	source, err := newRESTSource(ctx, endpoints, oauth2.StaticTokenSource(&oauth2.Token{
+		AccessToken: token,
+	}))
+	if err != nil {
+		return "", nil, err
+	}
+
+	return source.Scopes(ctx)
+}
@@ -0,0 +1,67 @@
+package github
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"reflect"
+	"testing"
+)
+
+func TestMissingScopes(t *testing.T) {
+	tests := []struct {
+		name    string
+		header  string
+		missing []string
+	}{
+		{name: "granted", header: "repo, read:org", missing: []string{}},
+		{name: "implied", header: "repo, admin:org, gist", missing: []string{}},
+		{name: "missing one", header: "repo", missing: []string{"read:org"}},
+		{name: "missing all", header: "", missing: []string{"repo", "read:org"}},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			if missing := MissingScopes(ParseScopes(test.header), REQUIRED_SCOPES); !reflect.DeepEqual(missing, test.missing) {
+				t.Fatalf("expected %v to be missing, found %v", test.missing, missing)
+			}
+		})
+	}
+}
+
+func TestTokenScopes(t *testing.T) {
+	mux := http.NewServeMux()
+	mux.HandleFunc("/api/v3/user", func(w http.ResponseWriter, r *http.Request) {
+		if r.Header.Get("Authorization") != "Bearer token" {
+			w.WriteHeader(http.StatusUnauthorized)
+			writeJSON(t, w, map[string]interface{}{"message": "Bad credentials"})
+			return
+		}
+
+		w.Header().Set(SCOPES_HEADER, "repo, read:org")
+		writeJSON(t, w, map[string]interface{}{"login": "octocat"})
+	})
+
+	server := httptest.NewServer(mux)
+	defer server.Close()
+
+	serverURL, _ := url.Parse(server.URL)
+	endpoints, err := NewEndpoints(serverURL.Host, server.URL, "")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	username, scopes, err := TokenScopes(context.Background(), endpoints, "token")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if username != "octocat" || !reflect.DeepEqual(scopes, []string{"repo", "read:org"}) {
+		t.Fatalf("expected octocat with the scopes [repo read:org], found %s with %v", username, scopes)
+	}
+
+	if _, _, err := TokenScopes(context.Background(), endpoints, "revoked"); err == nil {
+		t.Fatal("expected an invalid token to be reported")
+	}
+}
@@ -0,0 +1,73 @@
+package github
+
+import "time"
+
+// Snapshot is an immutable copy of a Poller's collections, as of a single poll;
+// unlike the collections themselves, a Snapshot can be read without holding
+// the Poller's lock. Its slices are never modified - by the Poller, or by
+// consumers - so a Snapshot may be freely shared between goroutines.
+type Snapshot struct {
+	// Sequence is incremented by every successful poll, starting at 1 for the
+	// initial request made by `NewPoller`; a Snapshot with a greater Sequence
+	// is always more recent.
+	Sequence uint64
+	// Time at which the Pull Requests were retrieved
+	FetchedAt time.Time
+	// Pull Requests assigned to the current user
+	Assigned []PullRequestSummary
+	// Pull Requests *created* by the current user
+	Created []PullRequestSummary
+	// Pull Requests where the current user's review is requested
+	ReviewRequested []PullRequestSummary
+	// Pull Requests matching each `SavedSearch`, in the same order as the searches
+	Searches [][]PullRequestSummary
+	// Pull Requests closed - or merged - within the Poller's `ClosedWindow`
+	RecentlyClosed []PullRequestSummary
+}
+
+// newSnapshot copies the sets into a new Snapshot, so that it doesn't share any
+// slices with the source - or the Poller's collections.
+func newSnapshot(sequence uint64, fetchedAt time.Time, sets *PullRequestSets) *Snapshot {
+	snapshot := &Snapshot{
+		Sequence:        sequence,
+		FetchedAt:       fetchedAt,
+		Assigned:        copyPullRequests(sets.Assigned),
+		Created:         copyPullRequests(sets.Created),
+		ReviewRequested: copyPullRequests(sets.ReviewRequested),
+		Searches:        make([][]PullRequestSummary, len(sets.Searches)),
+		RecentlyClosed:  copyPullRequests(sets.RecentlyClosed),
+	}
+
+	for idx, results := range sets.Searches {
+		snapshot.Searches[idx] = copyPullRequests(results)
+	}
+
+	return snapshot
+}
+
+// sets returns the Snapshot's collections as `PullRequestSets`, for comparison
+// with those most recently retrieved; the slices are shared, and so mustn't be
+// modified.
+func (snapshot *Snapshot) sets() *PullRequestSets {
+	return &PullRequestSets{
+		Assigned:        snapshot.Assigned,
+		Created:         snapshot.Created,
+		ReviewRequested: snapshot.ReviewRequested,
+		Searches:        snapshot.Searches,
+		RecentlyClosed:  snapshot.RecentlyClosed,
+	}
+}
+
+// Snapshot returns the most recent Snapshot of the Poller's collections.
+func (poller *Poller) Snapshot() *Snapshot {
+	poller.Lock()
+	defer poller.Unlock()
+
+	return poller.snapshot
+}
+
+func copyPullRequests(pullRequests []PullRequestSummary) []PullRequestSummary {
+	copied := make([]PullRequestSummary, len(pullRequests))
+	copy(copied, pullRequests)
+	return copied
+}
@@ -0,0 +1,151 @@
+package github
+
+import (
+	"context"
+	"fmt"
+	"reflect"
+	"sync"
+	"testing"
+	"time"
+)
+
+// pollSets returns distinct `PullRequestSets` for each poll, so that every
+// Snapshot differs from the last.
+func pollSets(poll int) *PullRequestSets {
+	pr := func(number int) PullRequestSummary {
+		return PullRequestSummary{
+			Repository: "o/r",
+			ID:         fmt.Sprint(number),
+			Title:      fmt.Sprintf("poll %d", poll),
+			Status:     STATUS_OPEN,
+			Comments:   poll,
+			URL:        fmt.Sprintf("https://github.com/o/r/pull/%d", number),
+		}
+	}
+
+	sets := &PullRequestSets{
+		Assigned:        []PullRequestSummary{pr(poll), pr(poll + 1), pr(poll + 2)},
+		Created:         []PullRequestSummary{pr(poll % 5)},
+		ReviewRequested: []PullRequestSummary{},
+	}
+
+	if poll%2 == 0 {
+		sets.ReviewRequested = append(sets.ReviewRequested, pr(poll+1))
+	}
+
+	return sets
+}
+
+func TestSnapshotConcurrentPolls(t *testing.T) {
+	const polls = 200
+
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+
+	search := SavedSearch{Name: "infra", Query: "org:infra"}
+	accounts := make([]*Account, 2)
+	for idx := range accounts {
+		sets := make([]*PullRequestSets, polls)
+		for poll := range sets {
+			sets[poll] = pollSets(poll)
+		}
+
+		source := NewFakeSource(fmt.Sprintf("user-%d", idx), sets...)
+		source.SetSearchResults(search.SearchQuery(), []PullRequestSummary{{Repository: "infra/r", ID: "1"}})
+
+		poller, err := NewPoller(ctx, source, []SavedSearch{search}, newFakeClock())
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		accounts[idx] = &Account{Host: DEFAULT_HOST, Poller: poller}
+	}
+
+	group := NewPollerGroup(accounts...)
+	poller := accounts[0].Poller
+	notifications := poller.Subscribe(ctx, nil)
+
+	// Each reader retains a copy of every Snapshot it observes, so they can be
+	// compared once polling has finished.
+	done := make(chan struct{})
+	observed := make([]map[*Snapshot]*Snapshot, 8)
+	var readers sync.WaitGroup
+	for reader := range observed {
+		observed[reader] = make(map[*Snapshot]*Snapshot)
+		readers.Add(1)
+		go func(seen map[*Snapshot]*Snapshot) {
+			defer readers.Done()
+
+			var sequence, groupSequence uint64
+			for {
+				select {
+				case <-done:
+					return
+				default:
+				}
+
+				snapshot := poller.Snapshot()
+				if snapshot.Sequence < sequence {
+					t.Errorf("Poller Sequence decreased from %d to %d", sequence, snapshot.Sequence)
+					return
+				}
+				sequence = snapshot.Sequence
+
+				if _, isSeen := seen[snapshot]; !isSeen {
+					seen[snapshot] = newSnapshot(snapshot.Sequence, snapshot.FetchedAt, snapshot.sets())
+				}
+
+				merged := group.Snapshot()
+				if merged.Sequence < groupSequence {
+					t.Errorf("PollerGroup Sequence decreased from %d to %d", groupSequence, merged.Sequence)
+					return
+				}
+				groupSequence = merged.Sequence
+			}
+		}(observed[reader])
+	}
+
+	group.Poll(time.Minute)
+
+	// Wait until the FakeSource has returned every set.
+	timeout := time.After(10 * time.Second)
+	for waiting := true; waiting; {
+		select {
+		case notification := <-notifications:
+			waiting = notification.Snapshot == nil || notification.Snapshot.Sequence <= polls
+		case <-timeout:
+			t.Fatal("timed out waiting for the Poller")
+		}
+	}
+
+	cancel()
+	close(done)
+	readers.Wait()
+
+	seen := 0
+	for _, snapshots := range observed {
+		for snapshot, copied := range snapshots {
+			if !reflect.DeepEqual(snapshot, copied) {
+				t.Fatalf("expected Snapshot %d to be unchanged by later polls, found %+v - was %+v", snapshot.Sequence, snapshot, copied)
+			}
+		}
+		seen += len(snapshots)
+	}
+
+	if seen < 2 {
+		t.Fatalf("expected the readers to observe multiple Snapshots, observed %d", seen)
+	}
+}
+
+func TestSnapshotDoesNotShareSlices(t *testing.T) {
+	sets := pollSets(0)
+	sets.Searches = [][]PullRequestSummary{{{Repository: "infra/r", ID: "1"}}}
+
+	snapshot := newSnapshot(1, time.Time{}, sets)
+	sets.Assigned[0].Title = "modified"
+	sets.Searches[0][0].Title = "modified"
+
+	if snapshot.Assigned[0].Title == "modified" || snapshot.Searches[0][0].Title == "modified" {
+		t.Fatal("expected the Snapshot not to share slices with the sets it was created from")
+	}
+}
@@ -0,0 +1,527 @@
+package github
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net/http"
+	"sync"
+
+	"github.com/google/go-github/v32/github"
+	"golang.org/x/oauth2"
+)
+
+const (
+	// API_V3 selects the REST based `PullRequestSource`; this requires one
+	// request per filter, and an additional request per Pull Request.
+	API_V3 = "v3"
+	// API_V4 selects the GraphQL based `PullRequestSource`; this retrieves all
+	// Pull Requests - and their details - via a single request. Note that its
+	// assigned Pull Requests only include those explicitly assigned to the user;
+	// see `ASSIGNED_QUERY`.
+	API_V4 = "v4"
+	// ISSUES_PAGE_SIZE is the number of Issues requested per page; this is the
+	// maximum permitted by Github.
+	ISSUES_PAGE_SIZE = 100
+	// DEFAULT_MAX_ITEMS is the maximum number of Pull Requests retrieved per
+	// filter, unless otherwise configured.
+	DEFAULT_MAX_ITEMS = 500
+	// REVIEWS_PAGE_SIZE is the number of reviews retrieved per Pull Request.
+	REVIEWS_PAGE_SIZE = 100
+	// CI_PAGE_SIZE is the number of statuses and check runs retrieved per commit.
+	CI_PAGE_SIZE = 100
+	// MAX_DETAILS_FAILURE_RATIO is the proportion of Pull Requests which may be
+	// skipped because their details couldn't be retrieved - although a single
+	// Pull Request may always be skipped; beyond this the poll fails, rather
+	// than returning a set which is missing Pull Requests.
+	MAX_DETAILS_FAILURE_RATIO = 0.1
+)
+
+// PullRequestSets contains the different sets of Pull Requests that are
+// retrieved by a `PullRequestSource` during a single poll.
+type PullRequestSets struct {
+	// Pull Requests assigned to the current user
+	Assigned []PullRequestSummary
+	// Pull Requests *created* by the current user
+	Created []PullRequestSummary
+	// Pull Requests where a review has been requested from the current user,
+	// either directly or via membership of a team
+	ReviewRequested []PullRequestSummary
+	// Pull Requests matching each of the Poller's `SavedSearch` queries, in the
+	// same order as the searches were configured
+	Searches [][]PullRequestSummary
+	// Pull Requests which have been closed - or merged - recently; this is
+	// populated by the Poller, rather than the `PullRequestSource`
+	RecentlyClosed []PullRequestSummary
+}
+
+// SavedSearch is a named Github search query; the Pull Requests matching the
+// query are retrieved alongside the standard sets during each poll.
+type SavedSearch struct {
+	// Name displayed alongside the results
+	Name string
+	// Github search query - i.e "org:infra label:needs-review"
+	Query string
+}
+
+// SearchQuery returns the query restricted to open Pull Requests; the results
+// would otherwise include Issues and closed Pull Requests.
+func (search SavedSearch) SearchQuery() string {
+	return "is:pr is:open " + search.Query
+}
+
+// PullRequestSource is the interface consumed by the Poller for retrieving
+// data from Github; it allows the underlying API to be selected at runtime,
+// and for the Poller to be used without access to api.github.com.
+type PullRequestSource interface {
+	// CurrentUser returns the username - or 'Login' - of the authenticated user.
+	CurrentUser(ctx context.Context) (string, error)
+	// PullRequests retrieves all sets of Pull Requests for the current user.
+	PullRequests(ctx context.Context) (*PullRequestSets, error)
+	// Search retrieves the Pull Requests matching a Github search query.
+	Search(ctx context.Context, query string) ([]PullRequestSummary, error)
+}
+
+// SourceConfig contains the configuration required by `NewSource`.
+type SourceConfig struct {
+	// Token used to authenticate with Github
+	Token string
+	// Credentials for authenticating as a Github App installation; if provided
+	// then these are used instead of the `Token`
+	App *AppCredentials
+	// Endpoints of the Github instance; github.com is used if not provided
+	Endpoints Endpoints
+	// Github API to use - either `API_V3` (REST) or `API_V4` (GraphQL)
+	APIVersion string
+	// Maximum number of concurrent requests; only used by the REST source
+	Concurrency int
+	// Maximum number of Pull Requests retrieved per filter - or search
+	MaxItems int
+	// Additional teams - as `org/team-slug` - to retrieve review requests for;
+	// only used by the REST source
+	ReviewTeams []string
+}
+
+// NewSource returns a `PullRequestSource` for the API version requested in the
+// `SourceConfig`. Requests are made via a `CachingTransport`, beneath the oauth2
+// transport. When authenticating as a Github App, the source is wrapped in an
+// `InstallationSource`.
+func NewSource(ctx context.Context, config SourceConfig) (PullRequestSource, error) {
+	endpoints := config.Endpoints
+	if endpoints.BaseURL == "" {
+		var err error
+		if endpoints, err = NewEndpoints(DEFAULT_HOST, "", ""); err != nil {
+			return nil, err
+		}
+	}
+
+	if config.App == nil {
+		return newSource(ctx, config, endpoints, oauth2.StaticTokenSource(&oauth2.Token{
+			AccessToken: config.Token,
+		}))
+	}
+
+	appTokenSource, err := NewAppTokenSource(ctx, endpoints, *config.App, nil, nil)
+	if err != nil {
+		return nil, err
+	}
+
+	source, err := newSource(ctx, config, endpoints, oauth2.ReuseTokenSource(nil, appTokenSource))
+	if err != nil {
+		return nil, err
+	}
+
+	return NewInstallationSource(source, appTokenSource), nil
+}
+
+func newSource(ctx context.Context, config SourceConfig, endpoints Endpoints, tokenSource oauth2.TokenSource) (PullRequestSource, error) {
+	switch config.APIVersion {
+	case API_V3:
+		source, err := newRESTSource(ctx, endpoints, tokenSource)
+		if err != nil {
+			return nil, err
+		}
+
+		if config.Concurrency > 0 {
+			source.Concurrency = config.Concurrency
+		}
+		if config.MaxItems > 0 {
+			source.MaxItems = config.MaxItems
+		}
+		source.ReviewTeams = config.ReviewTeams
+		return source, nil
+	case API_V4:
+		oauthClient, _ := newOAuthClient(ctx, tokenSource)
+		source := NewGraphQLSource(oauthClient, endpoints.GraphQLURL)
+		if config.MaxItems > 0 {
+			source.MaxItems = config.MaxItems
+		}
+		return source, nil
+	}
+
+	return nil, fmt.Errorf("unsupported github api version: %s", config.APIVersion)
+}
+
+// newRESTSource returns a `RESTSource` for the Github instance, with the default
+// configuration; it's used directly wherever the REST API is required, rather
+// than any `PullRequestSource`.
+func newRESTSource(ctx context.Context, endpoints Endpoints, tokenSource oauth2.TokenSource) (*RESTSource, error) {
+	oauthClient, cache := newOAuthClient(ctx, tokenSource)
+	client := github.NewClient(oauthClient)
+	if endpoints.Enterprise() {
+		enterpriseClient, err := github.NewEnterpriseClient(endpoints.BaseURL, endpoints.UploadURL, oauthClient)
+		if err != nil {
+			return nil, err
+		}
+
+		client = enterpriseClient
+	}
+
+	return NewRESTSource(client, cache), nil
+}
+
+// newOAuthClient returns an `http.Client` which authenticates via the token
+// source, making requests via a new `CachingTransport`.
+func newOAuthClient(ctx context.Context, tokenSource oauth2.TokenSource) (*http.Client, *CachingTransport) {
+	cache := NewCachingTransport(nil)
+	return oauth2.NewClient(
+		context.WithValue(ctx, oauth2.HTTPClient, &http.Client{Transport: cache}),
+		tokenSource,
+	), cache
+}
+
+// RESTSource retrieves Pull Requests via the v3 (REST) API. This is the
+// original implementation, and is rather wasteful in terms of requests: it
+// lists the assigned, created and review-requested Issues - the latter via the
+// Search API - and then fetches the details - along with reviews and CI status
+// - of each associated Pull Request individually. To reduce the time taken,
+// the details are retrieved concurrently via a bounded pool of workers.
+type RESTSource struct {
+	rateLimitTracker
+	client *github.Client
+	cache  *CachingTransport
+	// Maximum number of concurrent requests when retrieving details
+	Concurrency int
+	// Maximum number of Pull Requests retrieved per filter; this is a safety
+	// cap on the number of pages requested.
+	MaxItems int
+	// Additional teams - as `org/team-slug` - to retrieve review requests for
+	ReviewTeams []string
+}
+
+// NewRESTSource returns a `RESTSource` which uses the provided `github.Client`;
+// if the client makes requests via a `CachingTransport`, then it should also
+// be provided so its statistics can be reported - otherwise `cache` may be nil.
+func NewRESTSource(client *github.Client, cache *CachingTransport) *RESTSource {
+	return &RESTSource{
+		client:      client,
+		cache:       cache,
+		Concurrency: DEFAULT_CONCURRENCY,
+		MaxItems:    DEFAULT_MAX_ITEMS,
+	}
+}
+
+// CacheStats returns the statistics of the associated `CachingTransport`.
+func (source *RESTSource) CacheStats() CacheStats {
+	if source.cache == nil {
+		return CacheStats{}
+	}
+
+	return source.cache.Stats()
+}
+
+// CurrentUser retrieves the authenticated user via the Users API.
+func (source *RESTSource) CurrentUser(ctx context.Context) (string, error) {
+	currentUser, resp, err := source.client.Users.Get(ctx, "")
+	if err = source.observeREST(resp, err); err != nil {
+		return "", err
+	}
+
+	return currentUser.GetLogin(), nil
+}
+
+// PullRequests lists the assigned, created and review-requested Pull Requests
+// in parallel, and then retrieves the details of each.
+func (source *RESTSource) PullRequests(ctx context.Context) (*PullRequestSets, error) {
+	issueSets, err := source.listConcurrently(ctx,
+		func(ctx context.Context) ([]*github.Issue, error) {
+			return source.listPullRequestIssues(ctx, ASSIGNED_FILTER)
+		},
+		func(ctx context.Context) ([]*github.Issue, error) {
+			return source.listPullRequestIssues(ctx, CREATED_FILTER)
+		},
+		func(ctx context.Context) ([]*github.Issue, error) {
+			return source.searchPullRequestIssues(ctx, source.reviewRequestedQueries()...)
+		},
+	)
+	if err != nil {
+		return nil, err
+	}
+
+	// Retrieve the details for all sets via the same pool of workers, and then
+	// split the results back into their respective sets.
+	allIssues := make([]*github.Issue, 0)
+	for _, issues := range issueSets {
+		allIssues = append(allIssues, issues...)
+	}
+
+	summaries, err := source.details(ctx, allIssues)
+	if err != nil {
+		return nil, err
+	}
+
+	summarySets := make([][]PullRequestSummary, len(issueSets))
+	for idx, issues := range issueSets {
+		summarySets[idx] = compactSummaries(summaries[:len(issues)])
+		summaries = summaries[len(issues):]
+	}
+
+	return &PullRequestSets{
+		Assigned:        summarySets[0],
+		Created:         summarySets[1],
+		ReviewRequested: summarySets[2],
+	}, nil
+}
+
+// Search retrieves the Pull Requests matching a Github search query, along with
+// the details of each.
+func (source *RESTSource) Search(ctx context.Context, query string) ([]PullRequestSummary, error) {
+	issues, err := source.searchPullRequestIssues(ctx, query)
+	if err != nil {
+		return nil, err
+	}
+
+	summaries, err := source.details(ctx, issues)
+	if err != nil {
+		return nil, err
+	}
+
+	return compactSummaries(summaries), nil
+}
+
+func (source *RESTSource) listConcurrently(ctx context.Context, lists ...func(context.Context) ([]*github.Issue, error)) ([][]*github.Issue, error) {
+	// Execute each of the list functions in parallel, returning their results in
+	// the same order as provided - or the first error encountered.
+	var wg sync.WaitGroup
+	issueSets := make([][]*github.Issue, len(lists))
+	errs := make([]error, len(lists))
+
+	for idx, list := range lists {
+		wg.Add(1)
+		go func(idx int, list func(context.Context) ([]*github.Issue, error)) {
+			defer wg.Done()
+			issueSets[idx], errs[idx] = list(ctx)
+		}(idx, list)
+	}
+	wg.Wait()
+
+	for _, err := range errs {
+		if err != nil {
+			return nil, err
+		}
+	}
+
+	return issueSets, nil
+}
+
+func (source *RESTSource) reviewRequestedQueries() []string {
+	// `review-requested` includes requests made to any team the user is a member
+	// of; additional teams can be explicitly configured though.
+	queries := []string{REVIEW_REQUESTED_QUERY}
+	for _, team := range source.ReviewTeams {
+		queries = append(queries, fmt.Sprintf(TEAM_REVIEW_REQUESTED_QUERY, team))
+	}
+
+	return queries
+}
+
+func (source *RESTSource) listPullRequestIssues(ctx context.Context, filterString string) ([]*github.Issue, error) {
+	// Follow the pagination provided via the `Link` header, until either there
+	// are no further pages or we've retrieved `MaxItems` Pull Requests.
+	opts := &github.IssueListOptions{
+		Filter: filterString,
+		ListOptions: github.ListOptions{
+			PerPage: ISSUES_PAGE_SIZE,
+		},
+	}
+
+	pullRequestIssues := make([]*github.Issue, 0)
+	for {
+		issues, resp, err := source.client.Issues.List(ctx, true, opts)
+		if err = source.observeREST(resp, err); err != nil {
+			return nil, err
+		}
+
+		for _, issue := range issues {
+			if !issue.IsPullRequest() {
+				continue
+			}
+
+			pullRequestIssues = append(pullRequestIssues, issue)
+			if source.MaxItems > 0 && len(pullRequestIssues) >= source.MaxItems {
+				return pullRequestIssues, nil
+			}
+		}
+
+		if resp.NextPage == 0 {
+			return pullRequestIssues, nil
+		}
+
+		opts.Page = resp.NextPage
+	}
+}
+
+func (source *RESTSource) searchPullRequestIssues(ctx context.Context, queries ...string) ([]*github.Issue, error) {
+	// Execute each search query - following pagination - and combine the results;
+	// a Pull Request matching multiple queries is only included once.
+	seen := make(map[string]struct{})
+	pullRequestIssues := make([]*github.Issue, 0)
+
+	for _, query := range queries {
+		opts := &github.SearchOptions{
+			ListOptions: github.ListOptions{
+				PerPage: ISSUES_PAGE_SIZE,
+			},
+		}
+
+		for {
+			result, resp, err := source.client.Search.Issues(ctx, query, opts)
+			if err = source.observeREST(resp, err); err != nil {
+				return nil, err
+			}
+
+			for _, issue := range result.Issues {
+				if _, isSeen := seen[issue.GetHTMLURL()]; isSeen || !issue.IsPullRequest() {
+					continue
+				}
+
+				seen[issue.GetHTMLURL()] = struct{}{}
+				pullRequestIssues = append(pullRequestIssues, issue)
+				if source.MaxItems > 0 && len(pullRequestIssues) >= source.MaxItems {
+					return pullRequestIssues, nil
+				}
+			}
+
+			if resp.NextPage == 0 {
+				break
+			}
+
+			opts.Page = resp.NextPage
+		}
+	}
+
+	return pullRequestIssues, nil
+}
+
+func (source *RESTSource) details(ctx context.Context, issues []*github.Issue) ([]*PullRequestSummary, error) {
+	// Results are stored by index, so the ordering matches that of `issues`; a
+	// nil result indicates a Pull Request that couldn't be retrieved.
+	summaries := make([]*PullRequestSummary, len(issues))
+
+	var mutex sync.Mutex
+	var failures int
+	var lastErr error
+	err := runWorkerPool(ctx, source.Concurrency, len(issues), func(ctx context.Context, idx int) error {
+		summary, err := source.pullRequestDetails(ctx, issues[idx])
+
+		// A Pull Request that can't be retrieved is skipped, rather than
+		// discarding the entire set - unless we've hit the rate limit.
+		var rateErr *RateLimitError
+		if errors.As(err, &rateErr) {
+			return err
+		} else if err != nil {
+			mutex.Lock()
+			failures++
+			lastErr = err
+			mutex.Unlock()
+		} else {
+			summaries[idx] = &summary
+		}
+
+		return nil
+	})
+
+	if err != nil {
+		return nil, err
+	}
+
+	// Skipping more than the odd Pull Request would present a misleading set;
+	// i.e as though the others had been closed.
+	tolerated := int(float64(len(issues)) * MAX_DETAILS_FAILURE_RATIO)
+	if tolerated < 1 {
+		tolerated = 1
+	}
+
+	if failures > tolerated {
+		return nil, fmt.Errorf("unable to retrieve %d of %d pull requests: %w", failures, len(issues), lastErr)
+	}
+
+	return summaries, nil
+}
+
+func (source *RESTSource) pullRequestDetails(ctx context.Context, issue *github.Issue) (PullRequestSummary, error) {
+	// Retrieve the Pull Request itself, its reviews, and the CI status of its
+	// head commit; the latter is optional, as not every repository uses CI.
+	owner, repo := issueRepository(issue)
+	pullRequest, resp, err := source.client.PullRequests.Get(ctx, owner, repo, issue.GetNumber())
+	if err = source.observeREST(resp, err); err != nil {
+		return PullRequestSummary{}, err
+	}
+
+	reviews, resp, err := source.client.PullRequests.ListReviews(ctx, owner, repo, issue.GetNumber(), &github.ListOptions{
+		PerPage: REVIEWS_PAGE_SIZE,
+	})
+	if err = source.observeREST(resp, err); err != nil {
+		return PullRequestSummary{}, err
+	}
+
+	ciState, err := source.ciState(ctx, owner, repo, pullRequest.GetHead().GetSHA())
+	if err != nil {
+		return PullRequestSummary{}, err
+	}
+
+	summary := NewPullRequestFromAPI(issue, pullRequest, reviews)
+	summary.CIState = ciState
+	return summary, nil
+}
+
+func (source *RESTSource) ciState(ctx context.Context, owner, repo, sha string) (string, error) {
+	// Failures to retrieve either the statuses or check runs are ignored - i.e
+	// the token may lack the required permissions - unless rate limited.
+	status, resp, err := source.client.Repositories.GetCombinedStatus(ctx, owner, repo, sha, &github.ListOptions{
+		PerPage: CI_PAGE_SIZE,
+	})
+	if err = source.observeREST(resp, err); err != nil {
+		var rateErr *RateLimitError
+		if errors.As(err, &rateErr) {
+			return CI_NONE, err
+		}
+		status = nil
+	}
+
+	checkRuns, resp, err := source.client.Checks.ListCheckRunsForRef(ctx, owner, repo, sha, &github.ListCheckRunsOptions{
+		ListOptions: github.ListOptions{
+			PerPage: CI_PAGE_SIZE,
+		},
+	})
+	if err = source.observeREST(resp, err); err != nil {
+		var rateErr *RateLimitError
+		if errors.As(err, &rateErr) {
+			return CI_NONE, err
+		}
+		checkRuns = nil
+	}
+
+	return NewCIStateFromAPI(status, checkRuns), nil
+}
+
+func compactSummaries(summaries []*PullRequestSummary) []PullRequestSummary {
+	collection := make([]PullRequestSummary, 0, len(summaries))
+	for _, summary := range summaries {
+		if summary != nil {
+			collection = append(collection, *summary)
+		}
+	}
+
+	return collection
+}
@@ -0,0 +1,169 @@
+package github
+
+import (
+	"context"
+	"sync"
+)
+
+// FakeSource is an in-memory `PullRequestSource`, allowing the Poller to be
+// exercised without access to Github. It returns each of the configured
+// `PullRequestSets` in turn, and then continues to return the final set.
+type FakeSource struct {
+	mutex    sync.Mutex
+	username string
+	sets     []*PullRequestSets
+	searches map[string][]PullRequestSummary
+	resolved map[string]PullRequestSummary
+	clock    Clock
+	err      error
+	// Error returned by ResolveClosed alone
+	resolveErr error
+	calls      int
+	// Index of the next set to return
+	position int
+}
+
+// NewFakeSource returns a `FakeSource` for the user `username`, which will
+// return the provided `sets` - in order - from successive calls.
+func NewFakeSource(username string, sets ...*PullRequestSets) *FakeSource {
+	return &FakeSource{
+		username: username,
+		sets:     sets,
+		searches: make(map[string][]PullRequestSummary),
+		resolved: make(map[string]PullRequestSummary),
+		clock:    realClock{},
+	}
+}
+
+// CurrentUser returns the username provided when creating the FakeSource.
+func (source *FakeSource) CurrentUser(ctx context.Context) (string, error) {
+	source.mutex.Lock()
+	defer source.mutex.Unlock()
+
+	if source.err != nil {
+		return "", source.err
+	}
+
+	return source.username, nil
+}
+
+// PullRequests returns the next configured `PullRequestSets`, or the error
+// provided via `SetError`.
+func (source *FakeSource) PullRequests(ctx context.Context) (*PullRequestSets, error) {
+	source.mutex.Lock()
+	defer source.mutex.Unlock()
+
+	source.calls++
+	if source.err != nil {
+		return nil, source.err
+	}
+
+	if len(source.sets) == 0 {
+		return &PullRequestSets{}, nil
+	}
+
+	if source.position == len(source.sets) {
+		return source.sets[len(source.sets)-1], nil
+	}
+
+	source.position++
+	return source.sets[source.position-1], nil
+}
+
+// Search returns the results configured for `query` via `SetSearchResults`.
+func (source *FakeSource) Search(ctx context.Context, query string) ([]PullRequestSummary, error) {
+	source.mutex.Lock()
+	defer source.mutex.Unlock()
+
+	if source.err != nil {
+		return nil, source.err
+	}
+
+	return append([]PullRequestSummary{}, source.searches[query]...), nil
+}
+
+// SetSearchResults configures the results returned when searching for `query`.
+func (source *FakeSource) SetSearchResults(query string, results []PullRequestSummary) {
+	source.mutex.Lock()
+	defer source.mutex.Unlock()
+
+	source.searches[query] = results
+}
+
+// ResolveClosed returns the Pull Request configured via `SetResolved`; otherwise
+// the Pull Request is reported as having been closed now - as per the Clock
+// provided via `SetClock`.
+func (source *FakeSource) ResolveClosed(ctx context.Context, pr PullRequestSummary) (PullRequestSummary, error) {
+	source.mutex.Lock()
+	defer source.mutex.Unlock()
+
+	if source.err != nil {
+		return pr, source.err
+	}
+
+	if source.resolveErr != nil {
+		return pr, source.resolveErr
+	}
+
+	if resolved, isResolved := source.resolved[pr.Key()]; isResolved {
+		return resolved, nil
+	}
+
+	pr.Status = STATUS_CLOSED
+	pr.ClosedAt = source.clock.Now()
+	return pr, nil
+}
+
+// SetResolved configures the Pull Request returned by `ResolveClosed` for any
+// Pull Request with the same `Key`.
+func (source *FakeSource) SetResolved(pr PullRequestSummary) {
+	source.mutex.Lock()
+	defer source.mutex.Unlock()
+
+	source.resolved[pr.Key()] = pr
+}
+
+// Push appends further `PullRequestSets` to be returned by the FakeSource; they're
+// returned after any which haven't yet been, and the final set is then retained.
+func (source *FakeSource) Push(sets ...*PullRequestSets) {
+	source.mutex.Lock()
+	defer source.mutex.Unlock()
+
+	source.sets = append(source.sets, sets...)
+}
+
+// SetResolveError configures an error to be returned from subsequent calls to
+// `ResolveClosed` - without affecting any other methods; a nil error restores
+// normal behaviour.
+func (source *FakeSource) SetResolveError(err error) {
+	source.mutex.Lock()
+	defer source.mutex.Unlock()
+
+	source.resolveErr = err
+}
+
+// SetClock configures the Clock used when resolving closed Pull Requests; the
+// system clock is used by default.
+func (source *FakeSource) SetClock(clock Clock) {
+	source.mutex.Lock()
+	defer source.mutex.Unlock()
+
+	source.clock = clock
+}
+
+// SetError configures an error to be returned from all subsequent calls; a
+// nil error restores normal behaviour.
+func (source *FakeSource) SetError(err error) {
+	source.mutex.Lock()
+	defer source.mutex.Unlock()
+
+	source.err = err
+}
+
+// Calls returns the number of times `PullRequests` has been called.
+func (source *FakeSource) Calls() int {
+	source.mutex.Lock()
+	defer source.mutex.Unlock()
+
+	return source.calls
+}
@@ -0,0 +1,57 @@
+package github
+
+import (
+	"context"
+	"testing"
+)
+
+func TestFakeSourcePush(t *testing.T) {
+	first, second, third := pollSets(1), pollSets(2), pollSets(3)
+	source := NewFakeSource("user", first, second)
+
+	// Regression: the final set was retained when returned, and so returned once
+	// more before any set which was subsequently pushed.
+	expected := []*PullRequestSets{first, second, second, third, third}
+	for idx, sets := range expected {
+		if idx == 3 {
+			source.Push(third)
+		}
+
+		received, err := source.PullRequests(context.Background())
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		if received != sets {
+			t.Fatalf("call %d: expected the sets for poll %s, received %s", idx, sets.Assigned[0].Title, received.Assigned[0].Title)
+		}
+	}
+
+	if source.Calls() != len(expected) {
+		t.Fatalf("expected %d calls, found %d", len(expected), source.Calls())
+	}
+}
+
+func TestFakeSourceResolveClosed(t *testing.T) {
+	clock := newFakeClock()
+	source := NewFakeSource("user")
+	source.SetClock(clock)
+
+	resolved, err := source.ResolveClosed(context.Background(), fakePullRequest(1))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	// Regression: without a ClosedAt the Poller dropped the Pull Request from
+	// the recently closed collection on the following poll.
+	if resolved.Status != STATUS_CLOSED || !resolved.ClosedAt.Equal(clock.Now()) {
+		t.Fatalf("expected the Pull Request to be closed as of %s, found %s at %s", clock.Now(), resolved.Status, resolved.ClosedAt)
+	}
+
+	merged := fakePullRequest(1)
+	merged.Status = STATUS_MERGED
+	source.SetResolved(merged)
+	if resolved, _ := source.ResolveClosed(context.Background(), fakePullRequest(1)); resolved.Status != STATUS_MERGED {
+		t.Fatalf("expected the configured Pull Request, found %s", resolved.Status)
+	}
+}
@@ -0,0 +1,298 @@
+package github
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"reflect"
+	"strconv"
+	"strings"
+	"sync/atomic"
+	"testing"
+
+	"github.com/google/go-github/v32/github"
+)
+
+// newTestRESTSource returns a `RESTSource` whose requests are served by
+// `handler`, rather than the Github API.
+func newTestRESTSource(t *testing.T, handler http.Handler) *RESTSource {
+	t.Helper()
+
+	server := httptest.NewServer(handler)
+	t.Cleanup(server.Close)
+
+	client := github.NewClient(server.Client())
+	client.BaseURL, _ = url.Parse(server.URL + "/")
+	return NewRESTSource(client, nil)
+}
+
+// writeJSON responds with `body`, encoded as JSON.
+func writeJSON(t *testing.T, w http.ResponseWriter, body interface{}) {
+	w.Header().Set("Content-Type", "application/json")
+	if err := json.NewEncoder(w).Encode(body); err != nil {
+		t.Error(err)
+	}
+}
+
+// restIssue returns the representation of the Issue for Pull Request `number`,
+// as returned by the Issues and Search APIs.
+func restIssue(number int) map[string]interface{} {
+	return map[string]interface{}{
+		"number":         number,
+		"title":          fmt.Sprintf("Pull Request %d", number),
+		"state":          "open",
+		"html_url":       fmt.Sprintf("https://github.com/o/r/pull/%d", number),
+		"repository_url": "https://api.github.com/repos/o/r",
+		"user":           map[string]interface{}{"login": "author"},
+		"pull_request":   map[string]interface{}{"url": fmt.Sprintf("https://api.github.com/repos/o/r/pulls/%d", number)},
+	}
+}
+
+// restPullRequests serves the details of every Pull Request in the repository
+// `o/r`; those numbered in `failing` respond with an error.
+type restPullRequests struct {
+	failing map[int]bool
+}
+
+func (api *restPullRequests) register(t *testing.T, mux *http.ServeMux) {
+	mux.HandleFunc("/repos/o/r/pulls/", func(w http.ResponseWriter, r *http.Request) {
+		segments := strings.Split(strings.TrimPrefix(r.URL.Path, "/repos/o/r/pulls/"), "/")
+		number, err := strconv.Atoi(segments[0])
+		if err != nil {
+			http.NotFound(w, r)
+			return
+		}
+
+		if len(segments) > 1 {
+			writeJSON(t, w, []interface{}{})
+			return
+		}
+
+		if api.failing[number] {
+			http.Error(w, `{"message": "Server Error"}`, http.StatusInternalServerError)
+			return
+		}
+
+		writeJSON(t, w, map[string]interface{}{
+			"number":   number,
+			"state":    "open",
+			"html_url": fmt.Sprintf("https://github.com/o/r/pull/%d", number),
+			"head":     map[string]interface{}{"sha": fmt.Sprintf("sha%d", number)},
+		})
+	})
+
+	mux.HandleFunc("/repos/o/r/commits/", func(w http.ResponseWriter, r *http.Request) {
+		writeJSON(t, w, map[string]interface{}{})
+	})
+}
+
+// searchResults serves `count` Pull Requests - numbered from 1 - via a single
+// page of the Search API.
+func searchResults(t *testing.T, mux *http.ServeMux, count int) {
+	mux.HandleFunc("/search/issues", func(w http.ResponseWriter, r *http.Request) {
+		items := make([]interface{}, count)
+		for idx := range items {
+			items[idx] = restIssue(idx + 1)
+		}
+
+		writeJSON(t, w, map[string]interface{}{"total_count": count, "items": items})
+	})
+}
+
+func TestRESTSourceDetailsFailures(t *testing.T) {
+	tests := []struct {
+		name    string
+		count   int
+		failing []int
+		failed  bool
+	}{
+		{name: "none failing", count: 20},
+		{name: "trivial share failing", count: 20, failing: []int{7, 13}},
+		{name: "too many failing", count: 20, failing: []int{3, 7, 13}, failed: true},
+		{name: "one of a few failing", count: 3, failing: []int{2}},
+		{name: "two of a few failing", count: 3, failing: []int{1, 3}, failed: true},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			pullRequests := &restPullRequests{failing: make(map[int]bool)}
+			for _, number := range test.failing {
+				pullRequests.failing[number] = true
+			}
+
+			mux := http.NewServeMux()
+			pullRequests.register(t, mux)
+			searchResults(t, mux, test.count)
+
+			results, err := newTestRESTSource(t, mux).Search(context.Background(), "is:pr")
+			if test.failed {
+				if err == nil {
+					t.Fatalf("expected an error, received %d results", len(results))
+				}
+				return
+			}
+
+			if err != nil {
+				t.Fatal(err)
+			}
+
+			if expected := test.count - len(test.failing); len(results) != expected {
+				t.Fatalf("expected %d results, found %d", expected, len(results))
+			}
+
+			for _, pr := range results {
+				if number, _ := strconv.Atoi(pr.ID); pullRequests.failing[number] {
+					t.Fatalf("expected Pull Request %d to be skipped", number)
+				}
+			}
+		})
+	}
+}
+
+// paginatedIssues serves `pages` pages via `path`, linked via the `Link` header;
+// each page contains two Pull Requests - numbered sequentially from 1 - and an
+// Issue, which should be skipped. It returns the number of requests made.
+func paginatedIssues(t *testing.T, mux *http.ServeMux, path string, pages int, wrap func(items []interface{}) interface{}) *int32 {
+	var requests int32
+	mux.HandleFunc(path, func(w http.ResponseWriter, r *http.Request) {
+		atomic.AddInt32(&requests, 1)
+
+		page, err := strconv.Atoi(r.URL.Query().Get("page"))
+		if err != nil {
+			page = 1
+		}
+
+		if page < pages {
+			next := *r.URL
+			query := next.Query()
+			query.Set("page", strconv.Itoa(page+1))
+			next.RawQuery = query.Encode()
+			w.Header().Set("Link", fmt.Sprintf(`<http://%s%s>; rel="next"`, r.Host, next.String()))
+		}
+
+		issue := restIssue(0)
+		delete(issue, "pull_request")
+		writeJSON(t, w, wrap([]interface{}{restIssue(2*page - 1), restIssue(2 * page), issue}))
+	})
+
+	return &requests
+}
+
+func TestRESTSourcePagination(t *testing.T) {
+	lists := []struct {
+		name string
+		path string
+		wrap func(items []interface{}) interface{}
+		list func(ctx context.Context, source *RESTSource) ([]*github.Issue, error)
+	}{
+		{
+			name: "issues",
+			path: "/issues",
+			wrap: func(items []interface{}) interface{} { return items },
+			list: func(ctx context.Context, source *RESTSource) ([]*github.Issue, error) {
+				return source.listPullRequestIssues(ctx, ASSIGNED_FILTER)
+			},
+		},
+		{
+			name: "search",
+			path: "/search/issues",
+			wrap: func(items []interface{}) interface{} {
+				return map[string]interface{}{"total_count": len(items), "items": items}
+			},
+			list: func(ctx context.Context, source *RESTSource) ([]*github.Issue, error) {
+				return source.searchPullRequestIssues(ctx, REVIEW_REQUESTED_QUERY)
+			},
+		},
+	}
+
+	tests := []struct {
+		name     string
+		maxItems int
+		pages    int
+		results  int
+		requests int32
+	}{
+		{name: "single page", maxItems: 10, pages: 1, results: 2, requests: 1},
+		{name: "every page", maxItems: 10, pages: 3, results: 6, requests: 3},
+		{name: "capped mid-page", maxItems: 3, pages: 5, results: 3, requests: 2},
+		{name: "capped at a page boundary", maxItems: 4, pages: 5, results: 4, requests: 2},
+		{name: "uncapped", maxItems: 0, pages: 4, results: 8, requests: 4},
+	}
+
+	for _, list := range lists {
+		for _, test := range tests {
+			t.Run(list.name+": "+test.name, func(t *testing.T) {
+				mux := http.NewServeMux()
+				requests := paginatedIssues(t, mux, list.path, test.pages, list.wrap)
+
+				source := newTestRESTSource(t, mux)
+				source.MaxItems = test.maxItems
+
+				issues, err := list.list(context.Background(), source)
+				if err != nil {
+					t.Fatal(err)
+				}
+
+				if len(issues) != test.results {
+					t.Errorf("expected %d results, found %d", test.results, len(issues))
+				}
+
+				for idx, issue := range issues {
+					if issue.GetNumber() != idx+1 {
+						t.Fatalf("expected the Pull Requests from every page in order, found #%d at %d", issue.GetNumber(), idx)
+					}
+				}
+
+				if made := atomic.LoadInt32(requests); made != test.requests {
+					t.Errorf("expected %d requests, found %d", test.requests, made)
+				}
+			})
+		}
+	}
+}
+
+func TestRESTSourcePaginatedAssignedPullRequests(t *testing.T) {
+	mux := http.NewServeMux()
+	(&restPullRequests{}).register(t, mux)
+	searchResults(t, mux, 0)
+	mux.HandleFunc("/user", func(w http.ResponseWriter, r *http.Request) {
+		writeJSON(t, w, map[string]interface{}{"login": "octocat"})
+	})
+
+	// Only the assigned Pull Requests span multiple pages.
+	assigned := http.NewServeMux()
+	requests := paginatedIssues(t, assigned, "/issues", 3, func(items []interface{}) interface{} { return items })
+	mux.HandleFunc("/issues", func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Query().Get("filter") == ASSIGNED_FILTER {
+			assigned.ServeHTTP(w, r)
+			return
+		}
+
+		writeJSON(t, w, []interface{}{})
+	})
+
+	source := newTestRESTSource(t, mux)
+	source.MaxItems = 5
+
+	poller, err := NewPoller(context.Background(), source, nil, newFakeClock())
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	snapshot := poller.Snapshot()
+	if ids := ids(snapshot.Assigned); !reflect.DeepEqual(ids, []string{"1", "2", "3", "4", "5"}) {
+		t.Fatalf("expected the assigned Pull Requests from every page - up to the cap - found %v", ids)
+	}
+
+	if len(snapshot.Created) > 0 || len(snapshot.ReviewRequested) > 0 {
+		t.Errorf("expected only assigned Pull Requests, found %d created and %d review requested",
+			len(snapshot.Created), len(snapshot.ReviewRequested))
+	}
+
+	if made := atomic.LoadInt32(requests); made != 3 {
+		t.Errorf("expected 3 requests for the assigned Pull Requests, found %d", made)
+	}
+}
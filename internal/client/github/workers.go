@@ -0,0 +1,69 @@
+package github
+
+import (
+	"context"
+	"sync"
+)
+
+// DEFAULT_CONCURRENCY is the number of concurrent requests made when fetching
+// the details of individual Pull Requests, unless otherwise configured.
+const DEFAULT_CONCURRENCY = 4
+
+// runWorkerPool executes `job` for every index in the range [0, count), using
+// at most `concurrency` goroutines. Jobs are expected to store their results
+// by index - which keeps the ordering of any results deterministic.
+//
+// If any job returns an error then the context provided to the remaining jobs
+// is cancelled, no further jobs are started, and the first error is returned.
+func runWorkerPool(ctx context.Context, concurrency, count int, job func(context.Context, int) error) error {
+	if concurrency < 1 {
+		concurrency = 1
+	}
+
+	if concurrency > count {
+		concurrency = count
+	}
+
+	poolCtx, cancel := context.WithCancel(ctx)
+	defer cancel()
+
+	var (
+		wg       sync.WaitGroup
+		errOnce  sync.Once
+		firstErr error
+	)
+
+	indices := make(chan int)
+	for worker := 0; worker < concurrency; worker++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for idx := range indices {
+				if err := job(poolCtx, idx); err != nil {
+					errOnce.Do(func() {
+						firstErr = err
+						cancel()
+					})
+				}
+			}
+		}()
+	}
+
+dispatch:
+	for idx := 0; idx < count; idx++ {
+		select {
+		case indices <- idx:
+		case <-poolCtx.Done():
+			break dispatch
+		}
+	}
+
+	close(indices)
+	wg.Wait()
+
+	if firstErr != nil {
+		return firstErr
+	}
+
+	return ctx.Err()
+}
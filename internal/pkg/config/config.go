@@ -0,0 +1,488 @@
+// Package config provides the configuration for the `tui` app. Configuration
+// is read from a YAML file - by default located in the user's XDG config
+// directory - and is applied over a set of defaults; the calling package is
+// then expected to apply any command line flags over the top.
+//
+// The effective configuration can be rendered back to YAML via `Marshal`,
+// which allows users to generate a configuration file from the defaults.
+package config
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"strings"
+
+	"github.com/FergusInLondon/PRList/internal/client/github"
+	"github.com/FergusInLondon/PRList/internal/pkg/credentials"
+	"github.com/FergusInLondon/PRList/internal/pkg/tui"
+	"gopkg.in/yaml.v2"
+)
+
+const (
+	// APP_NAME is the name of the directory - within the XDG config directory -
+	// that contains the configuration file.
+	APP_NAME = "prmon"
+	// CONFIG_FILENAME is the name of the configuration file.
+	CONFIG_FILENAME = "config.yaml"
+	// TOKEN_FILENAME is the name of the token file - within the same directory
+	// as the configuration file - used unless another path is configured.
+	TOKEN_FILENAME = "token"
+
+	// PANE_ASSIGNED is the name of the pane listing assigned Pull Requests.
+	PANE_ASSIGNED = "assigned"
+	// PANE_CREATED is the name of the pane listing created Pull Requests.
+	PANE_CREATED = "created"
+	// PANE_REVIEW_REQUESTED is the name of the pane listing Pull Requests where
+	// the user's review has been requested.
+	PANE_REVIEW_REQUESTED = "review-requested"
+	// PANE_RECENTLY_CLOSED is the name of the pane listing Pull Requests which
+	// have recently been closed or merged.
+	PANE_RECENTLY_CLOSED = "recently-closed"
+
+	// REDACTED replaces any token when the configuration is marshalled.
+	REDACTED = "<redacted>"
+)
+
+// builtinPanes are the panes that don't require a query.
+var builtinPanes = map[string]bool{
+	PANE_ASSIGNED:         true,
+	PANE_CREATED:          true,
+	PANE_REVIEW_REQUESTED: true,
+	PANE_RECENTLY_CLOSED:  true,
+}
+
+// Config is the complete configuration for the `tui` app.
+type Config struct {
+	Token    Token     `yaml:"token"`
+	Github   Github    `yaml:"github"`
+	Accounts []Account `yaml:"accounts,omitempty"`
+	Poll     Poll      `yaml:"poll"`
+	Panes    []Pane    `yaml:"panes"`
+	Columns  []string  `yaml:"columns"`
+	Theme    Theme     `yaml:"theme"`
+	Keys     Keys      `yaml:"keys"`
+}
+
+// Token determines where the Github token is sourced from; each of the `Sources`
+// is tried in order, although an explicit value takes precedence over them all.
+// Tokens generated via the `login` command are persisted in the `Store`.
+type Token struct {
+	Sources []string `yaml:"sources"`
+	Store   string   `yaml:"store"`
+	Env     string   `yaml:"env"`
+	File    string   `yaml:"file,omitempty"`
+	Value   string   `yaml:"value,omitempty"`
+	App     *App     `yaml:"app,omitempty"`
+}
+
+// App contains the configuration for authenticating as an installation of a
+// Github App - i.e for a shared dashboard - rather than as a user; when present
+// no other token is used. `PrivateKey` is the path of the App's PEM encoded key.
+type App struct {
+	ID             int64  `yaml:"id"`
+	InstallationID int64  `yaml:"installation_id"`
+	PrivateKey     string `yaml:"private_key"`
+}
+
+// Github contains the configuration for accessing the Github API. For a Github
+// Enterprise Server only the `Host` is required, unless the API is served from
+// a non-standard location - in which case the `BaseURL` should be provided. The
+// `ClientID` identifies the OAuth App used by the `login` command.
+type Github struct {
+	Host        string   `yaml:"host"`
+	BaseURL     string   `yaml:"base_url,omitempty"`
+	UploadURL   string   `yaml:"upload_url,omitempty"`
+	API         string   `yaml:"api"`
+	Concurrency int      `yaml:"concurrency"`
+	MaxItems    int      `yaml:"max_items"`
+	ReviewTeams []string `yaml:"review_teams,omitempty"`
+	ClientID    string   `yaml:"client_id,omitempty"`
+}
+
+// Account is one of multiple Github accounts - potentially on different hosts -
+// to monitor simultaneously. Any values which aren't provided are inherited
+// from the top-level `Github` config - although the `BaseURL` and `UploadURL`
+// are only inherited by accounts on the same host - and the token `Sources` and
+// `Store` are inherited from the top-level `Token` config. The env, file, value
+// and app aren't inherited, so can't be set at the top-level alongside accounts.
+type Account struct {
+	Name   string `yaml:"name,omitempty"`
+	Token  Token  `yaml:"token"`
+	Github Github `yaml:"github"`
+}
+
+// Poll determines how frequently Github is polled; `Interval` is in minutes.
+// Closed Pull Requests are listed in the recently closed pane for the duration
+// of the `ClosedWindow`, in hours.
+type Poll struct {
+	Interval     int  `yaml:"interval"`
+	ClosedWindow int  `yaml:"closed_window"`
+	Debug        bool `yaml:"debug"`
+}
+
+// Pane is a Table displayed in the TUI; it's either one of the built-in
+// lists - identified by name - or a saved search when a `Query` is provided.
+type Pane struct {
+	Name  string `yaml:"name"`
+	Title string `yaml:"title,omitempty"`
+	Query string `yaml:"query,omitempty"`
+}
+
+// Theme contains the colours used to indicate good, neutral and bad states;
+// colours may be names - i.e "green" - or hex values.
+type Theme struct {
+	Good    string `yaml:"good"`
+	Default string `yaml:"default"`
+	Bad     string `yaml:"bad"`
+}
+
+// Keys contains the key bindings used by the TUI; bindings are either a single
+// character, or the name of a key - i.e "Tab".
+type Keys struct {
+	Open         string `yaml:"open"`
+	NextPane     string `yaml:"next_pane"`
+	PreviousPane string `yaml:"previous_pane"`
+	Refresh      string `yaml:"refresh"`
+}
+
+// Default returns the configuration used when no configuration file exists.
+func Default() *Config {
+	// The columns, colours and keys are validated by the TUI, which also
+	// provides their defaults.
+	tuiDefaults := tui.DefaultOptions()
+
+	return &Config{
+		Token: Token{
+			Sources: []string{
+				credentials.SOURCE_ENV,
+				credentials.SOURCE_KEYRING,
+				credentials.SOURCE_GH,
+				credentials.SOURCE_GIT,
+				credentials.SOURCE_FILE,
+			},
+			Store: credentials.SOURCE_KEYRING,
+			Env:   "GH_TOKEN",
+		},
+		Github: Github{
+			Host:        github.DEFAULT_HOST,
+			API:         github.API_V3,
+			Concurrency: github.DEFAULT_CONCURRENCY,
+			MaxItems:    github.DEFAULT_MAX_ITEMS,
+		},
+		Poll: Poll{Interval: 5, ClosedWindow: 24},
+		Panes: []Pane{
+			{Name: PANE_ASSIGNED},
+			{Name: PANE_CREATED},
+			{Name: PANE_REVIEW_REQUESTED},
+			{Name: PANE_RECENTLY_CLOSED},
+		},
+		Columns: tuiDefaults.Columns,
+		Theme: Theme{
+			Good:    tuiDefaults.Colours.Good,
+			Default: tuiDefaults.Colours.Default,
+			Bad:     tuiDefaults.Colours.Bad,
+		},
+		Keys: Keys{
+			Open:         tuiDefaults.Keys.Open,
+			NextPane:     tuiDefaults.Keys.NextPane,
+			PreviousPane: tuiDefaults.Keys.PreviousPane,
+			Refresh:      tuiDefaults.Keys.Refresh,
+		},
+	}
+}
+
+// DefaultPath returns the location of the configuration file within the XDG
+// config directory; falling back to `~/.config` when `$XDG_CONFIG_HOME` is unset.
+func DefaultPath() (string, error) {
+	configDir := os.Getenv("XDG_CONFIG_HOME")
+	if configDir == "" {
+		home, err := os.UserHomeDir()
+		if err != nil {
+			return "", fmt.Errorf("unable to determine config directory: %w", err)
+		}
+
+		configDir = filepath.Join(home, ".config")
+	}
+
+	return filepath.Join(configDir, APP_NAME, CONFIG_FILENAME), nil
+}
+
+// Load reads the configuration file at `path`, applying it over the defaults. A
+// missing file is only an error if `required` is set - i.e the path has been
+// explicitly provided by the user.
+func Load(path string, required bool) (*Config, error) {
+	config := Default()
+
+	contents, err := ioutil.ReadFile(path)
+	if err != nil {
+		if errors.Is(err, os.ErrNotExist) && !required {
+			return config, nil
+		}
+
+		return nil, fmt.Errorf("unable to read config file: %w", err)
+	}
+
+	// Any lists in the file replace the defaults entirely, rather than being
+	// merged with them.
+	if err := yaml.UnmarshalStrict(contents, config); err != nil {
+		return nil, fmt.Errorf("unable to parse config file '%s': %w", path, err)
+	}
+
+	return config, nil
+}
+
+// ResolveAccounts returns the Github accounts to monitor; when no accounts are
+// configured, then the top-level `Token` and `Github` config form the only one.
+func (config *Config) ResolveAccounts() []Account {
+	if len(config.Accounts) == 0 {
+		return []Account{{Token: config.Token, Github: config.Github}}
+	}
+
+	accounts := make([]Account, len(config.Accounts))
+	for idx, account := range config.Accounts {
+		if account.Github.Host == "" {
+			account.Github.Host = config.Github.Host
+		}
+
+		// The URLs are specific to the host, so are meaningless for any others.
+		if account.Github.Host == config.Github.Host {
+			if account.Github.BaseURL == "" {
+				account.Github.BaseURL = config.Github.BaseURL
+			}
+
+			if account.Github.UploadURL == "" {
+				account.Github.UploadURL = config.Github.UploadURL
+			}
+		}
+
+		if account.Github.API == "" {
+			account.Github.API = config.Github.API
+		}
+
+		if account.Github.Concurrency == 0 {
+			account.Github.Concurrency = config.Github.Concurrency
+		}
+
+		if account.Github.MaxItems == 0 {
+			account.Github.MaxItems = config.Github.MaxItems
+		}
+
+		if account.Token.Sources == nil {
+			account.Token.Sources = config.Token.Sources
+		}
+
+		if account.Token.Store == "" {
+			account.Token.Store = config.Token.Store
+		}
+
+		if account.Github.ClientID == "" {
+			account.Github.ClientID = config.Github.ClientID
+		}
+
+		if account.Github.ReviewTeams == nil {
+			account.Github.ReviewTeams = config.Github.ReviewTeams
+		}
+
+		accounts[idx] = account
+	}
+
+	return accounts
+}
+
+// Resolve returns the Github token for the host; either the explicitly configured
+// value, or the token from the first of the `Sources` able to supply one.
+func (token Token) Resolve(ctx context.Context, host string) (string, error) {
+	value, _, err := token.Lookup(ctx, host)
+	return value, err
+}
+
+// Lookup is equivalent to `Resolve`, but also describes where the token was
+// found.
+func (token Token) Lookup(ctx context.Context, host string) (string, string, error) {
+	if token.Value != "" {
+		return token.Value, "explicit value", nil
+	}
+
+	providers, err := token.Providers()
+	if err != nil {
+		return "", "", err
+	}
+
+	value, provider, err := credentials.Chain(providers).Lookup(ctx, host)
+	if err != nil {
+		return "", "", err
+	}
+
+	return value, provider.Name(), nil
+}
+
+// Providers returns a `credentials.Provider` for each of the `Sources`, in the
+// configured order.
+func (token Token) Providers() ([]credentials.Provider, error) {
+	providers := make([]credentials.Provider, 0, len(token.Sources))
+	for _, source := range token.Sources {
+		switch source {
+		case credentials.SOURCE_ENV:
+			providers = append(providers, credentials.EnvProvider{Variable: token.Env})
+		case credentials.SOURCE_KEYRING:
+			providers = append(providers, credentials.KeyringProvider{})
+		case credentials.SOURCE_GH:
+			providers = append(providers, credentials.GhProvider{})
+		case credentials.SOURCE_GIT:
+			providers = append(providers, credentials.GitProvider{})
+		case credentials.SOURCE_FILE:
+			path, err := token.filePath()
+			if err != nil {
+				return nil, err
+			}
+
+			providers = append(providers, credentials.FileProvider{Path: path})
+		default:
+			return nil, fmt.Errorf("invalid token source '%s'; expected one of %s, %s, %s, %s or %s", source,
+				credentials.SOURCE_ENV, credentials.SOURCE_KEYRING, credentials.SOURCE_GH, credentials.SOURCE_GIT, credentials.SOURCE_FILE)
+		}
+	}
+
+	return providers, nil
+}
+
+// TokenStore returns the `credentials.Store` in which tokens are persisted.
+func (token Token) TokenStore() (credentials.Store, error) {
+	switch token.Store {
+	case credentials.SOURCE_KEYRING:
+		return credentials.KeyringProvider{}, nil
+	case credentials.SOURCE_FILE:
+		path, err := token.filePath()
+		if err != nil {
+			return nil, err
+		}
+
+		return credentials.FileProvider{Path: path}, nil
+	}
+
+	return nil, fmt.Errorf("invalid token store '%s'; expected %s or %s", token.Store, credentials.SOURCE_KEYRING, credentials.SOURCE_FILE)
+}
+
+func (token Token) filePath() (string, error) {
+	if token.File != "" {
+		return token.File, nil
+	}
+
+	configPath, err := DefaultPath()
+	if err != nil {
+		return "", err
+	}
+
+	return filepath.Join(filepath.Dir(configPath), TOKEN_FILENAME), nil
+}
+
+// Validate checks the configuration for any invalid values; the TUI specific
+// values - columns, colours and keys - are validated by the TUI itself.
+func (config *Config) Validate() error {
+	if len(config.Accounts) > 0 {
+		if err := config.Token.validateWithAccounts(); err != nil {
+			return err
+		}
+	}
+
+	names := make(map[string]bool)
+	for _, account := range config.ResolveAccounts() {
+		if account.Github.API != github.API_V3 && account.Github.API != github.API_V4 {
+			return fmt.Errorf("invalid github api '%s'; expected %s or %s", account.Github.API, github.API_V3, github.API_V4)
+		}
+
+		if _, err := account.Token.Providers(); err != nil {
+			return err
+		}
+
+		if _, err := account.Token.TokenStore(); err != nil {
+			return err
+		}
+
+		if app := account.Token.App; app != nil && (app.ID == 0 || app.InstallationID == 0 || app.PrivateKey == "") {
+			return errors.New("invalid github app; the id, installation_id and private_key are all required")
+		}
+
+		if account.Name != "" && names[account.Name] {
+			return fmt.Errorf("invalid account '%s'; account names must be unique", account.Name)
+		}
+
+		names[account.Name] = true
+	}
+
+	if config.Poll.Interval < 1 {
+		return fmt.Errorf("invalid poll interval '%d'; must be at least 1 minute", config.Poll.Interval)
+	}
+
+	if config.Poll.ClosedWindow < 1 {
+		return fmt.Errorf("invalid closed window '%d'; must be at least 1 hour", config.Poll.ClosedWindow)
+	}
+
+	if len(config.Panes) == 0 {
+		return errors.New("no panes configured")
+	}
+
+	for _, pane := range config.Panes {
+		if strings.TrimSpace(pane.Name) == "" {
+			return errors.New("invalid pane; all panes require a name")
+		}
+
+		if pane.Query == "" && !builtinPanes[pane.Name] {
+			return fmt.Errorf("invalid pane '%s'; only the %s, %s, %s and %s panes can be used without a query",
+				pane.Name, PANE_ASSIGNED, PANE_CREATED, PANE_REVIEW_REQUESTED, PANE_RECENTLY_CLOSED)
+		}
+	}
+
+	return nil
+}
+
+// validateWithAccounts checks that none of the token values which aren't
+// inherited by accounts have been set, as they would otherwise be ignored.
+func (token Token) validateWithAccounts() error {
+	ignored := make([]string, 0)
+	if token.Value != "" {
+		ignored = append(ignored, "value")
+	}
+
+	if token.Env != Default().Token.Env {
+		ignored = append(ignored, "env")
+	}
+
+	if token.File != "" {
+		ignored = append(ignored, "file")
+	}
+
+	if token.App != nil {
+		ignored = append(ignored, "app")
+	}
+
+	if len(ignored) > 0 {
+		return fmt.Errorf("invalid token; the %s can't be set alongside accounts, configure each account's token instead",
+			strings.Join(ignored, ", "))
+	}
+
+	return nil
+}
+
+// Marshal renders the configuration as YAML; any explicit tokens are redacted.
+func (config *Config) Marshal() ([]byte, error) {
+	redacted := *config
+	if redacted.Token.Value != "" {
+		redacted.Token.Value = REDACTED
+	}
+
+	redacted.Accounts = make([]Account, len(config.Accounts))
+	for idx, account := range config.Accounts {
+		if account.Token.Value != "" {
+			account.Token.Value = REDACTED
+		}
+
+		redacted.Accounts[idx] = account
+	}
+
+	return yaml.Marshal(&redacted)
+}
@@ -0,0 +1,104 @@
+package config
+
+import (
+	"strings"
+	"testing"
+)
+
+func TestResolveAccounts(t *testing.T) {
+	tests := []struct {
+		name      string
+		host      string
+		baseURL   string
+		uploadURL string
+		expected  Github
+	}{
+		{
+			name:     "inherited",
+			expected: Github{Host: "ghes.example.com", BaseURL: "https://ghes.example.com/github/api/v3/", UploadURL: "https://uploads.example.com/"},
+		},
+		{
+			name:     "same host",
+			host:     "ghes.example.com",
+			expected: Github{Host: "ghes.example.com", BaseURL: "https://ghes.example.com/github/api/v3/", UploadURL: "https://uploads.example.com/"},
+		},
+		{
+			name:     "overridden",
+			baseURL:  "https://ghes.example.com/api/v3/",
+			expected: Github{Host: "ghes.example.com", BaseURL: "https://ghes.example.com/api/v3/", UploadURL: "https://uploads.example.com/"},
+		},
+		{
+			name:     "different host",
+			host:     "github.com",
+			expected: Github{Host: "github.com"},
+		},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			config := Default()
+			config.Github.Host = "ghes.example.com"
+			config.Github.BaseURL = "https://ghes.example.com/github/api/v3/"
+			config.Github.UploadURL = "https://uploads.example.com/"
+			config.Accounts = []Account{{Github: Github{Host: test.host, BaseURL: test.baseURL, UploadURL: test.uploadURL}}}
+
+			accounts := config.ResolveAccounts()
+			if len(accounts) != 1 {
+				t.Fatalf("expected a single account, found %d", len(accounts))
+			}
+
+			github := accounts[0].Github
+			if github.Host != test.expected.Host || github.BaseURL != test.expected.BaseURL || github.UploadURL != test.expected.UploadURL {
+				t.Errorf("expected the host %s, base url '%s' and upload url '%s'; found %s, '%s' and '%s'",
+					test.expected.Host, test.expected.BaseURL, test.expected.UploadURL, github.Host, github.BaseURL, github.UploadURL)
+			}
+
+			if github.API != config.Github.API || github.Concurrency != config.Github.Concurrency || github.MaxItems != config.Github.MaxItems {
+				t.Errorf("expected the remaining values to be inherited, found %+v", github)
+			}
+		})
+	}
+}
+
+func TestValidateTokenWithAccounts(t *testing.T) {
+	tests := []struct {
+		name  string
+		token func(token *Token)
+		err   string
+	}{
+		{name: "defaults", token: func(token *Token) {}},
+		{name: "value", token: func(token *Token) { token.Value = "token" }, err: "the value can't be set"},
+		{name: "env", token: func(token *Token) { token.Env = "WORK_TOKEN" }, err: "the env can't be set"},
+		{name: "file", token: func(token *Token) { token.File = "/tmp/token" }, err: "the file can't be set"},
+		{
+			name:  "app",
+			token: func(token *Token) { token.App = &App{ID: 1, InstallationID: 2, PrivateKey: "key.pem"} },
+			err:   "the app can't be set",
+		},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			config := Default()
+			test.token(&config.Token)
+
+			// Without accounts the token is used directly, so is always valid.
+			if err := config.Validate(); err != nil {
+				t.Fatalf("expected the token to be valid without accounts, found %v", err)
+			}
+
+			config.Accounts = []Account{{Name: "work"}}
+			err := config.Validate()
+			if test.err == "" {
+				if err != nil {
+					t.Fatal(err)
+				}
+				return
+			}
+
+			if err == nil || !strings.Contains(err.Error(), test.err) {
+				t.Fatalf("expected the error '%s', found %v", test.err, err)
+			}
+		})
+	}
+}
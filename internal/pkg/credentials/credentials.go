@@ -0,0 +1,130 @@
+// Package credentials sources the tokens used to authenticate with Github.
+// Tokens provided via flags or environment variables can leak into shell
+// history and process listings, so tokens can instead be retrieved from the
+// Secret Service keyring, the `gh` CLI's configuration, a git credential
+// helper, or a file only readable by the current user.
+//
+// Each of these is implemented as a `Provider`, and a `Chain` of Providers is
+// tried in the configured order until one of them supplies a token.
+package credentials
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"os"
+	"strings"
+)
+
+const (
+	// SOURCE_ENV retrieves the token from an environment variable.
+	SOURCE_ENV = "env"
+	// SOURCE_KEYRING retrieves the token from the Secret Service keyring.
+	SOURCE_KEYRING = "keyring"
+	// SOURCE_GH retrieves the token from the `gh` CLI's hosts.yml.
+	SOURCE_GH = "gh"
+	// SOURCE_GIT retrieves the token via `git credential fill`.
+	SOURCE_GIT = "git"
+	// SOURCE_FILE retrieves the token from a file with 0600 permissions.
+	SOURCE_FILE = "file"
+)
+
+// ErrNotFound is returned by a Provider which has no token for the host; as
+// opposed to a Provider which failed whilst looking for one.
+var ErrNotFound = errors.New("no token found")
+
+// Provider retrieves the token for a Github host.
+type Provider interface {
+	// Name describes the Provider - and where it looks - in error messages.
+	Name() string
+	// Token returns the token for the host - i.e "github.com" - or `ErrNotFound`.
+	Token(ctx context.Context, host string) (string, error)
+}
+
+// Store is implemented by any Provider which can also persist a token.
+type Store interface {
+	Provider
+	// Store persists the token for the host, replacing any existing token.
+	Store(ctx context.Context, host, token string) error
+}
+
+// Attempt records why a Provider was unable to supply a token.
+type Attempt struct {
+	Provider string
+	Err      error
+}
+
+// NotFoundError is returned by a `Chain` when none of its Providers were able
+// to supply a token; it describes every Provider that was attempted.
+type NotFoundError struct {
+	Host     string
+	Attempts []Attempt
+}
+
+func (err *NotFoundError) Error() string {
+	if len(err.Attempts) == 0 {
+		return fmt.Sprintf("no github token found for host '%s'; no token sources are configured", err.Host)
+	}
+
+	attempts := make([]string, len(err.Attempts))
+	for idx, attempt := range err.Attempts {
+		attempts[idx] = fmt.Sprintf("%s: %s", attempt.Provider, attempt.Err)
+	}
+
+	return fmt.Sprintf("no github token found for host '%s'; tried %s", err.Host, strings.Join(attempts, ", "))
+}
+
+// Chain is a list of Providers, tried in order.
+type Chain []Provider
+
+// Token returns the token from the first Provider able to supply one; if none
+// can, then a `NotFoundError` is returned.
+func (chain Chain) Token(ctx context.Context, host string) (string, error) {
+	token, _, err := chain.Lookup(ctx, host)
+	return token, err
+}
+
+// Lookup is equivalent to `Token`, but also returns the Provider which supplied
+// the token.
+func (chain Chain) Lookup(ctx context.Context, host string) (string, Provider, error) {
+	notFound := &NotFoundError{Host: host}
+	for _, provider := range chain {
+		token, err := provider.Token(ctx, host)
+		if err == nil && token != "" {
+			return token, provider, nil
+		}
+
+		if err == nil {
+			err = ErrNotFound
+		}
+
+		notFound.Attempts = append(notFound.Attempts, Attempt{Provider: provider.Name(), Err: err})
+	}
+
+	return "", nil, notFound
+}
+
+// EnvProvider retrieves the token from an environment variable; this is used
+// for all hosts.
+type EnvProvider struct {
+	Variable string
+}
+
+// Name describes the Provider, including the environment variable.
+func (provider EnvProvider) Name() string {
+	return fmt.Sprintf("%s ($%s)", SOURCE_ENV, provider.Variable)
+}
+
+// Token returns the value of the environment variable.
+func (provider EnvProvider) Token(ctx context.Context, host string) (string, error) {
+	if provider.Variable == "" {
+		return "", errors.New("no environment variable configured")
+	}
+
+	token := strings.TrimSpace(os.Getenv(provider.Variable))
+	if token == "" {
+		return "", ErrNotFound
+	}
+
+	return token, nil
+}
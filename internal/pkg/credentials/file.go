@@ -0,0 +1,91 @@
+package credentials
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"strings"
+)
+
+// FileProvider retrieves the token from a file, which must only be accessible
+// by the current user - i.e have permissions of 0600 or stricter. The token is
+// used for all hosts.
+type FileProvider struct {
+	Path string
+}
+
+// Name describes the Provider, including the path of the file.
+func (provider FileProvider) Name() string {
+	return fmt.Sprintf("%s (%s)", SOURCE_FILE, provider.Path)
+}
+
+// Token returns the contents of the file, after checking its permissions.
+func (provider FileProvider) Token(ctx context.Context, host string) (string, error) {
+	if provider.Path == "" {
+		return "", errors.New("no file configured")
+	}
+
+	info, err := os.Stat(provider.Path)
+	if err != nil {
+		if errors.Is(err, os.ErrNotExist) {
+			return "", ErrNotFound
+		}
+
+		return "", err
+	}
+
+	if info.Mode().Perm()&0077 != 0 {
+		return "", fmt.Errorf("permissions of %#o are too open; the file must have permissions of 0600", info.Mode().Perm())
+	}
+
+	contents, err := ioutil.ReadFile(provider.Path)
+	if err != nil {
+		return "", err
+	}
+
+	token := strings.TrimSpace(string(contents))
+	if token == "" {
+		return "", ErrNotFound
+	}
+
+	return token, nil
+}
+
+// Store writes the token to the file - with permissions of 0600 - creating any
+// parent directories as required.
+func (provider FileProvider) Store(ctx context.Context, host, token string) error {
+	if provider.Path == "" {
+		return errors.New("no file configured")
+	}
+
+	dir := filepath.Dir(provider.Path)
+	if err := os.MkdirAll(dir, 0700); err != nil {
+		return fmt.Errorf("unable to create directory for token file: %w", err)
+	}
+
+	// The token is written to a new file - which TempFile creates with 0600 - and
+	// then moved into place; so it's never readable via an existing file with
+	// looser permissions, and a partially written token is never observed.
+	file, err := ioutil.TempFile(dir, "."+filepath.Base(provider.Path)+".*")
+	if err != nil {
+		return fmt.Errorf("unable to write token file: %w", err)
+	}
+	defer os.Remove(file.Name())
+
+	_, err = file.WriteString(token + "\n")
+	if closeErr := file.Close(); err == nil {
+		err = closeErr
+	}
+	if err != nil {
+		return fmt.Errorf("unable to write token file: %w", err)
+	}
+
+	if err := os.Rename(file.Name(), provider.Path); err != nil {
+		return fmt.Errorf("unable to write token file: %w", err)
+	}
+
+	return nil
+}
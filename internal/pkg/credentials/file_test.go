@@ -0,0 +1,55 @@
+package credentials
+
+import (
+	"context"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+func TestFileProviderStore(t *testing.T) {
+	dir, err := ioutil.TempDir("", "credentials")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(dir)
+
+	// An existing file with looser permissions must never contain the token.
+	path := filepath.Join(dir, "config", "token")
+	if err := os.MkdirAll(filepath.Dir(path), 0700); err != nil {
+		t.Fatal(err)
+	}
+
+	if err := ioutil.WriteFile(path, []byte("previous\n"), 0644); err != nil {
+		t.Fatal(err)
+	}
+
+	provider := FileProvider{Path: path}
+	if err := provider.Store(context.Background(), "github.com", "token"); err != nil {
+		t.Fatal(err)
+	}
+
+	info, err := os.Stat(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if info.Mode().Perm() != 0600 {
+		t.Fatalf("expected permissions of 0600, found %#o", info.Mode().Perm())
+	}
+
+	token, err := provider.Token(context.Background(), "github.com")
+	if err != nil || token != "token" {
+		t.Fatalf("expected the stored token, found '%s' (%v)", token, err)
+	}
+
+	entries, err := ioutil.ReadDir(filepath.Dir(path))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if len(entries) != 1 {
+		t.Fatalf("expected only the token file, found %d files", len(entries))
+	}
+}
@@ -0,0 +1,90 @@
+package credentials
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+
+	"gopkg.in/yaml.v2"
+)
+
+// GH_HOSTS_FILENAME is the name of the file in which the `gh` CLI stores the
+// token for each host.
+const GH_HOSTS_FILENAME = "hosts.yml"
+
+// ghHost is the configuration stored by the `gh` CLI for each host; only the
+// token is of interest.
+type ghHost struct {
+	OAuthToken string `yaml:"oauth_token"`
+}
+
+// GhProvider retrieves tokens from the `gh` CLI's hosts.yml; this only contains
+// the token if `gh` isn't configured to use the system keyring itself.
+type GhProvider struct {
+	// Path of hosts.yml; if empty, then the location used by `gh` is used.
+	Path string
+}
+
+// Name describes the Provider, including the location of hosts.yml.
+func (provider GhProvider) Name() string {
+	path, err := provider.path()
+	if err != nil {
+		return SOURCE_GH
+	}
+
+	return fmt.Sprintf("%s (%s)", SOURCE_GH, path)
+}
+
+// Token returns the `oauth_token` for the host from hosts.yml.
+func (provider GhProvider) Token(ctx context.Context, host string) (string, error) {
+	path, err := provider.path()
+	if err != nil {
+		return "", err
+	}
+
+	contents, err := ioutil.ReadFile(path)
+	if err != nil {
+		if errors.Is(err, os.ErrNotExist) {
+			return "", ErrNotFound
+		}
+
+		return "", err
+	}
+
+	hosts := make(map[string]ghHost)
+	if err := yaml.Unmarshal(contents, &hosts); err != nil {
+		return "", fmt.Errorf("unable to parse %s: %w", GH_HOSTS_FILENAME, err)
+	}
+
+	if hosts[host].OAuthToken == "" {
+		return "", ErrNotFound
+	}
+
+	return hosts[host].OAuthToken, nil
+}
+
+func (provider GhProvider) path() (string, error) {
+	// Mirror `gh`, which respects both $GH_CONFIG_DIR and $XDG_CONFIG_HOME.
+	if provider.Path != "" {
+		return provider.Path, nil
+	}
+
+	if configDir := os.Getenv("GH_CONFIG_DIR"); configDir != "" {
+		return filepath.Join(configDir, GH_HOSTS_FILENAME), nil
+	}
+
+	configDir := os.Getenv("XDG_CONFIG_HOME")
+	if configDir == "" {
+		home, err := os.UserHomeDir()
+		if err != nil {
+			return "", fmt.Errorf("unable to determine gh config directory: %w", err)
+		}
+
+		configDir = filepath.Join(home, ".config")
+	}
+
+	return filepath.Join(configDir, "gh", GH_HOSTS_FILENAME), nil
+}
@@ -0,0 +1,56 @@
+package credentials
+
+import (
+	"bufio"
+	"bytes"
+	"context"
+	"fmt"
+	"os"
+	"os/exec"
+	"strings"
+)
+
+// GitProvider retrieves tokens via `git credential fill`; allowing any git
+// credential helper - i.e the macOS keychain, or Git Credential Manager - to
+// supply the token for a host.
+type GitProvider struct{}
+
+// Name describes the Provider.
+func (provider GitProvider) Name() string {
+	return SOURCE_GIT
+}
+
+// Token asks git for the credentials of `https://<host>`, returning the password.
+func (provider GitProvider) Token(ctx context.Context, host string) (string, error) {
+	if _, err := exec.LookPath("git"); err != nil {
+		return "", fmt.Errorf("git is not installed")
+	}
+
+	var stdout, stderr bytes.Buffer
+	cmd := exec.CommandContext(ctx, "git", "credential", "fill")
+	cmd.Stdin = strings.NewReader(fmt.Sprintf("protocol=https\nhost=%s\n\n", host))
+	cmd.Stdout = &stdout
+	cmd.Stderr = &stderr
+
+	// Without a helper able to supply the credentials, git would otherwise
+	// prompt for them - which would interfere with the TUI.
+	cmd.Env = append(os.Environ(), "GIT_TERMINAL_PROMPT=0", "GIT_ASKPASS=", "SSH_ASKPASS=")
+
+	if err := cmd.Run(); err != nil {
+		return "", fmt.Errorf("no credential helper supplied a token (%s)", commandError(err, stderr))
+	}
+
+	// The response is a series of `key=value` lines.
+	scanner := bufio.NewScanner(&stdout)
+	for scanner.Scan() {
+		if password := strings.TrimPrefix(scanner.Text(), "password="); password != scanner.Text() {
+			if password == "" {
+				break
+			}
+
+			return password, nil
+		}
+	}
+
+	return "", ErrNotFound
+}
@@ -0,0 +1,88 @@
+package credentials
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"fmt"
+	"os/exec"
+	"strings"
+)
+
+const (
+	// KEYRING_SERVICE is the value of the `service` attribute of any tokens
+	// stored in the keyring.
+	KEYRING_SERVICE = "prmon"
+	// SECRET_TOOL is the libsecret CLI used to access the Secret Service.
+	SECRET_TOOL = "secret-tool"
+)
+
+// KeyringProvider retrieves tokens from the Secret Service keyring - i.e GNOME
+// Keyring or KWallet - via `secret-tool`. Tokens are stored with the `service`
+// and `host` attributes; allowing a token per Github host.
+type KeyringProvider struct{}
+
+// Name describes the Provider.
+func (provider KeyringProvider) Name() string {
+	return SOURCE_KEYRING
+}
+
+// Token looks up the token for the host in the keyring.
+func (provider KeyringProvider) Token(ctx context.Context, host string) (string, error) {
+	if _, err := exec.LookPath(SECRET_TOOL); err != nil {
+		return "", fmt.Errorf("%s is not installed", SECRET_TOOL)
+	}
+
+	var stdout, stderr bytes.Buffer
+	cmd := exec.CommandContext(ctx, SECRET_TOOL, "lookup", "service", KEYRING_SERVICE, "host", host)
+	cmd.Stdout = &stdout
+	cmd.Stderr = &stderr
+
+	if err := cmd.Run(); err != nil {
+		// `secret-tool` exits with a status of 1 - and no output - when there's
+		// no matching secret.
+		var exitErr *exec.ExitError
+		if errors.As(err, &exitErr) && strings.TrimSpace(stderr.String()) == "" {
+			return "", ErrNotFound
+		}
+
+		return "", fmt.Errorf("unable to query keyring: %s", commandError(err, stderr))
+	}
+
+	token := strings.TrimSpace(stdout.String())
+	if token == "" {
+		return "", ErrNotFound
+	}
+
+	return token, nil
+}
+
+// Store saves the token for the host in the keyring; any existing token for
+// the host is replaced.
+func (provider KeyringProvider) Store(ctx context.Context, host, token string) error {
+	if _, err := exec.LookPath(SECRET_TOOL); err != nil {
+		return fmt.Errorf("%s is not installed", SECRET_TOOL)
+	}
+
+	// The token is provided via stdin, so it's not visible in process listings.
+	var stderr bytes.Buffer
+	cmd := exec.CommandContext(ctx, SECRET_TOOL, "store",
+		"--label", fmt.Sprintf("Github token for %s (%s)", host, KEYRING_SERVICE),
+		"service", KEYRING_SERVICE, "host", host)
+	cmd.Stdin = strings.NewReader(token)
+	cmd.Stderr = &stderr
+
+	if err := cmd.Run(); err != nil {
+		return fmt.Errorf("unable to store token in keyring: %s", commandError(err, stderr))
+	}
+
+	return nil
+}
+
+func commandError(err error, stderr bytes.Buffer) string {
+	if msg := strings.TrimSpace(stderr.String()); msg != "" {
+		return msg
+	}
+
+	return err.Error()
+}
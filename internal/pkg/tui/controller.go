@@ -3,7 +3,8 @@
 //
 // It's unlikely that the calling package will require interactions beyond
 // the three exposed functions available via `Controller`: `NewController`,
-// `Update`, and `Run`.
+// `Update`, and `Run`. The presentation of the TUI - the columns displayed,
+// colours, and key bindings - can be customised via `Options`.
 //
 // Additionally, a `State` struct is available which wraps around all the
 // State that can be displayed to the user. This struct is used for when
@@ -21,40 +22,78 @@ import (
 )
 
 var (
-	statusForegroundColours = map[string]tcell.Color{
-		"bad":     tcell.GetColor("red"),
-		"default": tcell.GetColor("yellow"),
-		"good":    tcell.GetColor("green"),
-	}
 	statusBackgroundColours = map[string]tcell.Color{}
 )
 
 // Controller exposes no properties, and acts as an interface for managing the TUI.
 type Controller struct {
-	app             *tview.Application
-	assignedPRTable *Table
-	createdPRTable  *Table
-	statusBar       *StatusBar
+	app       *tview.Application
+	options   *resolvedOptions
+	tables    []*Table
+	statusBar *StatusBar
+}
+
+// Pane is a titled collection of Pull Requests, which is displayed as a Table;
+// the TUI renders a Table for each Pane provided to `NewController`. A Dimmed
+// Pane - i.e of closed Pull Requests - is displayed without variable styling.
+type Pane struct {
+	Title        string
+	PullRequests []github.PullRequestSummary
+	Dimmed       bool
 }
 
 // State contains all the data required by the UI, it also acts as part of the
-// interface for updating the UI.
+// interface for updating the UI. When updating, both Accounts and Panes are
+// matched by position with those provided to `NewController`; a nil Account
+// indicates that there's no update for that Account.
 type State struct {
-	GithubUsername string
-	PollInterval   int
-	Assigned       []github.PullRequestSummary
-	Created        []github.PullRequestSummary
-	LastSync       time.Time
+	PollInterval int
+	Accounts     []*AccountState
+	Panes        []Pane
+}
+
+// AccountState contains the status of a single Github account; the TUI can
+// display the Pull Requests from multiple accounts, with the status of each
+// account displayed in the StatusBar.
+type AccountState struct {
+	Username   string
+	Host       string
+	LastSync   time.Time
+	LastError  error
+	RateLimit  github.RateLimit
+	CacheStats github.CacheStats
+	// Syncing indicates a poll is in progress; it's cleared by the next update
+	// with either a LastSync or LastError
+	Syncing bool
 }
 
 // NewController initialises all required UI components, returning a Controller
-// that's ready to execute  and function.
-func NewController(state *State) *Controller {
-	return &Controller{
-		assignedPRTable: NewTable("Assigned Pull Requests", PullRequestCollection{state.Assigned}),
-		createdPRTable:  NewTable("Created Pull Requests", PullRequestCollection{state.Created}),
-		statusBar:       NewStatusBar(state.GithubUsername, state.PollInterval, state.LastSync),
+// that's ready to execute  and function. If `options` is nil then the defaults
+// are used; an error is returned if any of the options are invalid.
+func NewController(state *State, options *Options) (*Controller, error) {
+	resolved, err := resolveOptions(options)
+	if err != nil {
+		return nil, err
+	}
+
+	accounts := make([]AccountState, len(state.Accounts))
+	for idx, account := range state.Accounts {
+		if account != nil {
+			accounts[idx] = *account
+		}
+	}
+
+	controller := &Controller{
+		options:   resolved,
+		tables:    make([]*Table, len(state.Panes)),
+		statusBar: NewStatusBar(state.PollInterval, accounts),
 	}
+
+	for idx, pane := range state.Panes {
+		controller.tables[idx] = NewTable(pane.Title, resolved.columns, resolved.open, resolved.refreshKey, resolved.refresh, controller.collection(pane))
+	}
+
+	return controller, nil
 }
 
 // Update accepts a State struct, and conditionally updates the applicable UI
@@ -62,31 +101,43 @@ func NewController(state *State) *Controller {
 // race conditions.
 func (tui *Controller) Update(newState *State) {
 	tui.app.QueueUpdateDraw(func() {
-		if len(newState.Assigned) > 0 {
-			tui.assignedPRTable.Update(PullRequestCollection{newState.Assigned})
+		// A nil collection indicates no update, whereas an empty collection
+		// indicates that the table should be emptied.
+		for idx, pane := range newState.Panes {
+			if idx < len(tui.tables) && pane.PullRequests != nil {
+				tui.tables[idx].Update(tui.collection(pane))
+			}
 		}
 
-		if len(newState.Created) > 0 {
-			tui.assignedPRTable.Update(PullRequestCollection{newState.Created})
+		for idx, account := range newState.Accounts {
+			if account != nil && idx < len(tui.statusBar.accounts) {
+				tui.updateAccount(idx, account)
+			}
 		}
-
-		tui.statusBar.Update(newState.LastSync)
 	})
 }
 
 // Run executes the `tview.App` - enabling the TUI. Execution can be stopped by
 // cancelling the provided Context.
 func (tui *Controller) Run(ctx context.Context) error {
+	// Each Table receives an equal share of the available space, with the
+	// StatusBar occupying a single row - per account - beneath them.
+	rows := make([]int, len(tui.tables)+1)
+	rows[len(tui.tables)] = tui.statusBar.Height()
+
 	grid := tview.NewGrid().
-		SetRows(0, 0, 1).
-		SetBorders(true).
-		AddItem(tui.assignedPRTable.Primitive, 0, 0, 1, 1, 0, 0, true).
-		AddItem(tui.createdPRTable.Primitive, 1, 0, 1, 1, 0, 0, false).
-		AddItem(tui.statusBar.Primitive, 2, 0, 1, 1, 0, 0, false)
+		SetRows(rows...).
+		SetBorders(true)
+
+	for idx, table := range tui.tables {
+		grid.AddItem(table.Primitive, idx, 0, 1, 1, 0, 0, idx == 0)
+	}
+	grid.AddItem(tui.statusBar.Primitive, len(tui.tables), 0, 1, 1, 0, 0, false)
 
 	tui.app = tview.NewApplication()
 	tui.app.SetRoot(grid, true).
 		SetFocus(grid).
+		SetInputCapture(tui.handlerEventKey).
 		EnableMouse(true)
 
 	go func(ctx context.Context) {
@@ -96,3 +147,64 @@ func (tui *Controller) Run(ctx context.Context) error {
 
 	return tui.app.Run()
 }
+
+func (tui *Controller) updateAccount(idx int, account *AccountState) {
+	if account.RateLimit.Known() {
+		tui.statusBar.UpdateRateLimit(idx, account.RateLimit)
+	}
+
+	if account.CacheStats.Hits+account.CacheStats.Misses > 0 {
+		tui.statusBar.UpdateCacheStats(idx, account.CacheStats)
+	}
+
+	if account.LastError != nil {
+		tui.statusBar.UpdateError(idx, account.LastError)
+	} else if !account.LastSync.IsZero() {
+		tui.statusBar.Update(idx, account.LastSync)
+	}
+
+	if account.Syncing {
+		tui.statusBar.UpdateSyncing(idx)
+	}
+}
+
+func (tui *Controller) collection(pane Pane) PullRequestCollection {
+	return PullRequestCollection{
+		PullReqs: pane.PullRequests,
+		Columns:  tui.options.columns,
+		Colours:  tui.options.colours,
+		Dimmed:   pane.Dimmed,
+	}
+}
+
+func (tui *Controller) handlerEventKey(evt *tcell.EventKey) *tcell.EventKey {
+	// By default Tab and Shift+Tab cycle the focus between Tables.
+	switch {
+	case tui.options.nextPane.Matches(evt):
+		tui.cycleFocus(1)
+		return nil
+	case tui.options.previousPane.Matches(evt):
+		tui.cycleFocus(-1)
+		return nil
+	}
+
+	return evt
+}
+
+func (tui *Controller) cycleFocus(step int) {
+	if len(tui.tables) == 0 {
+		return
+	}
+
+	// The focus may have been changed via the mouse, so determine the currently
+	// focused Table rather than tracking it.
+	current := 0
+	for idx, table := range tui.tables {
+		if table.Primitive.HasFocus() {
+			current = idx
+		}
+	}
+
+	next := (current + step + len(tui.tables)) % len(tui.tables)
+	tui.app.SetFocus(tui.tables[next].Primitive)
+}
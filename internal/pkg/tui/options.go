@@ -0,0 +1,184 @@
+package tui
+
+import (
+	"fmt"
+	"strings"
+
+	"github.com/gdamore/tcell/v2"
+)
+
+// Options customise the presentation of - and interaction with - the TUI. Any
+// zero values are replaced with those from `DefaultOptions`.
+type Options struct {
+	// Columns displayed in each Table, in order; see `ColumnNames`.
+	Columns []string
+	// Colours used to indicate the state of a Pull Request.
+	Colours Colours
+	// Keys bound to actions within the TUI.
+	Keys KeyBindings
+	// Refresh is called when the refresh key is pressed, and should request an
+	// immediate poll; if nil, then the key does nothing.
+	Refresh func()
+}
+
+// Colours contains the colours used to indicate good, neutral and bad states;
+// either the name of a colour - i.e "green" - or a hex value - i.e "#00FF00".
+type Colours struct {
+	Good    string
+	Default string
+	Bad     string
+}
+
+// KeyBindings contains the keys bound to actions within the TUI; either a single
+// character - i.e "o" - or the name of a key - i.e "Tab" or "Ctrl-O".
+type KeyBindings struct {
+	Open         string
+	NextPane     string
+	PreviousPane string
+	Refresh      string
+}
+
+// DefaultOptions returns the Options used when none are provided.
+func DefaultOptions() *Options {
+	return &Options{
+		Columns: []string{
+			COLUMN_REPOSITORY, COLUMN_ID, COLUMN_AUTHOR, COLUMN_TITLE,
+			COLUMN_REVIEWERS, COLUMN_STATUS, COLUMN_AGE,
+		},
+		Colours: Colours{
+			Good:    "green",
+			Default: "yellow",
+			Bad:     "red",
+		},
+		Keys: KeyBindings{
+			Open:         "o",
+			NextPane:     "Tab",
+			PreviousPane: "Backtab",
+			Refresh:      "r",
+		},
+	}
+}
+
+// KeyBinding matches a `tcell.EventKey` against either a single character, or
+// a named key.
+type KeyBinding struct {
+	key  tcell.Key
+	char rune
+}
+
+// ParseKeyBinding parses a key binding; either a single character, or one of
+// the key names used by tcell - i.e "Tab" or "Ctrl-O". Names are matched case
+// insensitively.
+func ParseKeyBinding(binding string) (KeyBinding, error) {
+	if chars := []rune(binding); len(chars) == 1 {
+		return KeyBinding{key: tcell.KeyRune, char: chars[0]}, nil
+	}
+
+	for key, name := range tcell.KeyNames {
+		if strings.EqualFold(name, binding) {
+			return KeyBinding{key: key}, nil
+		}
+	}
+
+	return KeyBinding{}, fmt.Errorf("unknown key '%s'", binding)
+}
+
+// Matches determines whether a given `tcell.EventKey` triggers the binding.
+func (binding KeyBinding) Matches(evt *tcell.EventKey) bool {
+	if binding.key == tcell.KeyRune {
+		return evt.Key() == tcell.KeyRune && evt.Rune() == binding.char
+	}
+
+	return evt.Key() == binding.key
+}
+
+// resolvedOptions are Options which have been validated, and parsed into the
+// types required by the TUI.
+type resolvedOptions struct {
+	columns      []string
+	colours      map[string]tcell.Color
+	open         KeyBinding
+	nextPane     KeyBinding
+	previousPane KeyBinding
+	refreshKey   KeyBinding
+	refresh      func()
+}
+
+func resolveOptions(options *Options) (*resolvedOptions, error) {
+	// Start from the defaults, so that callers only need to provide the Options
+	// that they wish to override.
+	defaults := DefaultOptions()
+	if options == nil {
+		options = defaults
+	}
+
+	columns := options.Columns
+	if len(columns) == 0 {
+		columns = defaults.Columns
+	}
+
+	for _, name := range columns {
+		if _, isKnown := pullRequestColumns[name]; !isKnown {
+			return nil, fmt.Errorf("unknown column '%s'; expected one of %s", name, strings.Join(ColumnNames(), ", "))
+		}
+	}
+
+	resolved := &resolvedOptions{
+		columns: columns,
+		colours: make(map[string]tcell.Color),
+		refresh: options.Refresh,
+	}
+
+	for _, colour := range []struct {
+		key, value, fallback string
+	}{
+		{"good", options.Colours.Good, defaults.Colours.Good},
+		{"default", options.Colours.Default, defaults.Colours.Default},
+		{"bad", options.Colours.Bad, defaults.Colours.Bad},
+	} {
+		parsed, err := parseColour(colour.value, colour.fallback)
+		if err != nil {
+			return nil, err
+		}
+
+		resolved.colours[colour.key] = parsed
+	}
+
+	for _, binding := range []struct {
+		target          *KeyBinding
+		value, fallback string
+	}{
+		{&resolved.open, options.Keys.Open, defaults.Keys.Open},
+		{&resolved.nextPane, options.Keys.NextPane, defaults.Keys.NextPane},
+		{&resolved.previousPane, options.Keys.PreviousPane, defaults.Keys.PreviousPane},
+		{&resolved.refreshKey, options.Keys.Refresh, defaults.Keys.Refresh},
+	} {
+		if binding.value == "" {
+			binding.value = binding.fallback
+		}
+
+		parsed, err := ParseKeyBinding(binding.value)
+		if err != nil {
+			return nil, err
+		}
+
+		*binding.target = parsed
+	}
+
+	return resolved, nil
+}
+
+func parseColour(colour, fallback string) (tcell.Color, error) {
+	// `tcell.GetColor` returns the default colour for anything it doesn't
+	// recognise, so unknown colours need to be detected prior to parsing.
+	if colour == "" {
+		colour = fallback
+	}
+
+	colour = strings.ToLower(colour)
+	if _, isNamed := tcell.ColorNames[colour]; !isNamed && !(len(colour) == 7 && colour[0] == '#') {
+		return tcell.ColorDefault, fmt.Errorf("unknown colour '%s'", colour)
+	}
+
+	return tcell.GetColor(colour), nil
+}
@@ -1,8 +1,9 @@
 package tui
 
 import (
+	"fmt"
+	"sort"
 	"strconv"
-	"strings"
 	"time"
 
 	"github.com/FergusInLondon/PRList/internal/client/github"
@@ -11,17 +12,95 @@ import (
 	"github.com/rivo/tview"
 )
 
+const (
+	// COLUMN_REPOSITORY displays the repository containing the Pull Request
+	COLUMN_REPOSITORY = "repository"
+	// COLUMN_ID displays the number of the Pull Request
+	COLUMN_ID = "id"
+	// COLUMN_AUTHOR displays the author of the Pull Request
+	COLUMN_AUTHOR = "author"
+	// COLUMN_TITLE displays the title of the Pull Request
+	COLUMN_TITLE = "title"
+	// COLUMN_REVIEWERS displays the reviewer count and review decision
+	COLUMN_REVIEWERS = "reviewers"
+	// COLUMN_STATUS displays the CI status, or the state of the Pull Request
+	COLUMN_STATUS = "status"
+	// COLUMN_AGE displays the time since the Pull Request was opened
+	COLUMN_AGE = "age"
+	// COLUMN_ACCOUNT displays the account the Pull Request was retrieved via;
+	// this is only useful when monitoring multiple accounts
+	COLUMN_ACCOUNT = "account"
+)
+
+// column defines how a column is displayed. Plain text columns only require a
+// `value`, whereas styled columns provide a `cell` - along with a `draftValue`,
+// as Draft rows are displayed without any styling. Closed rows are dimmed too,
+// and display the `closedValue` if provided - otherwise the `draftValue`.
+type column struct {
+	title       string
+	value       func(pr PullRequestRow) string
+	cell        func(pr PullRequestRow) *tview.TableCell
+	draftValue  string
+	closedValue func(pr PullRequestRow) string
+}
+
+var (
+	// pullRequestColumns contains all the columns that can be displayed in a
+	// Table, keyed by the name used to configure them.
+	pullRequestColumns = map[string]column{
+		COLUMN_REPOSITORY: {title: "Repository", value: func(pr PullRequestRow) string { return pr.Repository }},
+		COLUMN_ID:         {title: "ID", value: func(pr PullRequestRow) string { return pr.ID }},
+		COLUMN_AUTHOR:     {title: "Author", value: func(pr PullRequestRow) string { return pr.Author }},
+		COLUMN_TITLE:      {title: "Title", value: func(pr PullRequestRow) string { return pr.Title }},
+		COLUMN_REVIEWERS:  {title: "Reviewers", cell: PullRequestRow.reviewerCountCell, draftValue: "-"},
+		COLUMN_STATUS:     {title: "Status", cell: PullRequestRow.statusCell, draftValue: "draft", closedValue: PullRequestRow.closedStatus},
+		COLUMN_AGE:        {title: "Age", cell: PullRequestRow.openedAtCell, draftValue: "-", closedValue: PullRequestRow.closedAge},
+		COLUMN_ACCOUNT:    {title: "Account", value: func(pr PullRequestRow) string { return pr.Account }},
+	}
+	// reviewDecisionLabels are the labels displayed alongside the reviewer count.
+	reviewDecisionLabels = map[string]string{
+		github.REVIEW_APPROVED:          "approved",
+		github.REVIEW_CHANGES_REQUESTED: "changes requested",
+		github.REVIEW_COMMENTED:         "commented",
+		github.REVIEW_PENDING:           "pending",
+	}
+	// ciStatusPalette maps a CI state onto the keys of the status colour maps.
+	ciStatusPalette = map[string]string{
+		github.CI_SUCCESS: "good",
+		github.CI_PENDING: "default",
+		github.CI_FAILURE: "bad",
+		github.CI_ERROR:   "bad",
+	}
+)
+
+// ColumnNames returns the names of all the columns that can be displayed.
+func ColumnNames() []string {
+	names := make([]string, 0, len(pullRequestColumns))
+	for name := range pullRequestColumns {
+		names = append(names, name)
+	}
+
+	sort.Strings(names)
+	return names
+}
+
 // PullRequestCollection provides a `RowCollection` interface for a collection
-// of `github.PullRequestSummary` structs.
+// of `github.PullRequestSummary` structs; displaying the configured `Columns`,
+// and styling cells with the configured `Colours` - unless the collection is
+// `Dimmed`, as is the case for closed Pull Requests.
 type PullRequestCollection struct {
 	PullReqs []github.PullRequestSummary
+	Columns  []string
+	Colours  map[string]tcell.Color
+	Dimmed   bool
 }
 
 // PopulateTable populates a provided `tview.Table` with rows associated with
 // available `github.PullRequestSummary` structs.
 func (collection PullRequestCollection) PopulateTable(table *tview.Table) {
 	for idx, pullReq := range collection.PullReqs {
-		(PullRequestRow{pullReq}).Cells(idx+1, table)
+		row := PullRequestRow{PullRequestSummary: pullReq, colours: collection.Colours, closed: collection.Dimmed}
+		row.Cells(idx+1, collection.Columns, table)
 	}
 }
 
@@ -29,26 +108,31 @@ func (collection PullRequestCollection) PopulateTable(table *tview.Table) {
 // providing a display layer.
 type PullRequestRow struct {
 	github.PullRequestSummary
+	colours map[string]tcell.Color
+	closed  bool
 }
 
 // Cells generates all the `tview.TableCell` structs required for a row representing
 // a `github.PullRequestSummary`. It additionally sets the meta-data - i.e Reference
 // - of the given Row.
-func (pr PullRequestRow) Cells(idx int, table *tview.Table) {
-	if pr.Draft {
-		// Draft Rows are slightly different; no variable styling, and dimmed text
-		pr.draftRow(idx, table)
+func (pr PullRequestRow) Cells(idx int, columns []string, table *tview.Table) {
+	if pr.Draft || pr.closed {
+		// Draft - and closed - Rows are slightly different; no variable styling,
+		// and dimmed text
+		pr.dimmedRow(idx, columns, table)
 		pr.setReference(idx, table)
 		return
 	}
 
-	table.SetCell(idx, 0, tview.NewTableCell(pr.Repository).SetAlign(tview.AlignCenter))
-	table.SetCell(idx, 1, tview.NewTableCell(pr.ID).SetAlign(tview.AlignCenter))
-	table.SetCell(idx, 2, tview.NewTableCell(pr.Author).SetAlign(tview.AlignCenter))
-	table.SetCell(idx, 3, tview.NewTableCell(pr.Title).SetAlign(tview.AlignCenter))
-	table.SetCell(idx, 4, pr.reviewerCountCell())
-	table.SetCell(idx, 5, pr.statusCell())
-	table.SetCell(idx, 6, pr.openedAtCell())
+	for colIdx, name := range columns {
+		col := pullRequestColumns[name]
+		if col.cell == nil {
+			table.SetCell(idx, colIdx, tview.NewTableCell(col.value(pr)).SetAlign(tview.AlignCenter))
+			continue
+		}
+
+		table.SetCell(idx, colIdx, col.cell(pr))
+	}
 	pr.setReference(idx, table)
 }
 
@@ -59,13 +143,19 @@ func (pr PullRequestRow) setReference(idx int, table *tview.Table) {
 }
 
 func (pr PullRequestRow) statusCell() *tview.TableCell {
-	// provide basic formating on the status of a given PullRequest
-	statusCell := tview.NewTableCell(pr.Status).
+	// provide basic formating on the CI status of a given PullRequest; if there's
+	// no CI associated with the PullRequest, then fallback to the Issue state.
+	statusText := pr.Status
+	if pr.CIState != github.CI_NONE {
+		statusText = pr.CIState
+	}
+
+	statusCell := tview.NewTableCell(statusText).
 		SetAttributes(tcell.AttrBold).
 		SetAlign(tview.AlignCenter)
 
-	statusKey := strings.ToLower(pr.Status)
-	if textColour, hasColour := statusForegroundColours[statusKey]; hasColour {
+	statusKey := ciStatusPalette[pr.CIState]
+	if textColour, hasColour := pr.colours[statusKey]; hasColour {
 		statusCell.SetTextColor(textColour)
 	}
 
@@ -77,17 +167,26 @@ func (pr PullRequestRow) statusCell() *tview.TableCell {
 }
 
 func (pr PullRequestRow) reviewerCountCell() *tview.TableCell {
-	// provide basic formating on the number of reviewers for a given PullRequest
-	textColour := "red"
-	if pr.ReviewerCount > 0 {
-		textColour = "green"
+	// provide basic formating on the number of reviewers for a given PullRequest,
+	// along with the overall review decision - coloured by how favourable it is.
+	text := strconv.Itoa(pr.ReviewerCount)
+	if label, hasLabel := reviewDecisionLabels[pr.Review.Decision]; hasLabel {
+		text = fmt.Sprintf("%d (%s)", pr.ReviewerCount, label)
+	}
+
+	textColour := pr.colours["bad"]
+	switch pr.Review.Decision {
+	case github.REVIEW_APPROVED:
+		textColour = pr.colours["good"]
+	case github.REVIEW_COMMENTED, github.REVIEW_PENDING:
+		textColour = pr.colours["default"]
 	}
 
 	return tview.
-		NewTableCell(strconv.Itoa(pr.ReviewerCount)).
+		NewTableCell(text).
 		SetAlign(tview.AlignCenter).
 		SetAttributes(tcell.AttrBold).
-		SetTextColor(tcell.GetColor(textColour))
+		SetTextColor(textColour)
 }
 
 func (pr PullRequestRow) openedAtCell() *tview.TableCell {
@@ -110,12 +209,27 @@ func (pr PullRequestRow) openedAtCell() *tview.TableCell {
 		SetTextColor(textColour)
 }
 
-func (pr PullRequestRow) draftRow(idx int, table *tview.Table) {
-	// Draft Rows are just dimmed with minimal styling.
-	rowContents := []string{pr.Repository, pr.ID, pr.Author, pr.Title, "-", "draft", "-"}
+func (pr PullRequestRow) closedStatus() string {
+	return pr.Status
+}
+
+func (pr PullRequestRow) closedAge() string {
+	// The age of a closed PullRequest is the time since it was closed.
+	return prettyPrintDuration(int(time.Now().Sub(pr.ClosedAt).Seconds())) + " ago"
+}
 
-	for colIdx, colVal := range rowContents {
-		cell := tview.NewTableCell(colVal).
+func (pr PullRequestRow) dimmedRow(idx int, columns []string, table *tview.Table) {
+	// Draft - and closed - Rows are just dimmed with minimal styling.
+	for colIdx, name := range columns {
+		col := pullRequestColumns[name]
+		text := col.draftValue
+		if col.value != nil {
+			text = col.value(pr)
+		} else if pr.closed && col.closedValue != nil {
+			text = col.closedValue(pr)
+		}
+
+		cell := tview.NewTableCell(text).
 			SetAlign(tview.AlignCenter).
 			SetAttributes(tcell.AttrDim)
 
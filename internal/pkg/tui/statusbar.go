@@ -2,49 +2,162 @@ package tui
 
 import (
 	"fmt"
+	"strings"
 	"time"
 
+	"github.com/FergusInLondon/PRList/internal/client/github"
 	"github.com/rivo/tview"
 )
 
 const (
 	// STATUS_FORMAT_STR is the format string for use with fmt.Sprintf, providing
 	// the contents of the associated StatusBar in the UI.
-	STATUS_FORMAT_STR = "[#AAAAAA]Signed in as [::b]%s[::-]. Polling at [::b]%d[::-] minute intervals. (Last synchronised at [::b]%s[::-])[-]"
+	STATUS_FORMAT_STR = "[#AAAAAA]Signed in as [::b]%s[::-] on [::b]%s[::-]. Polling at [::b]%d[::-] minute intervals. (Last synchronised at [::b]%s[::-])[-]"
+	// STATUS_ERROR_FORMAT_STR is the format string used when the most recent
+	// sync has failed; it includes the error, as well as the last successful sync.
+	STATUS_ERROR_FORMAT_STR = "[#AAAAAA]Signed in as [::b]%s[::-] on [::b]%s[::-]. [red]Sync failed: [::b]%s[::-][#AAAAAA] (Last synchronised at [::b]%s[::-])[-]"
+	// STATUS_RATE_LIMIT_FORMAT_STR is appended to the contents of the StatusBar
+	// once the rate limit status of the Github API is known.
+	STATUS_RATE_LIMIT_FORMAT_STR = " [#AAAAAA]API quota: [::b]%d/%d[::-] (resets at [::b]%s[::-])[-]"
+	// STATUS_CACHE_FORMAT_STR is appended to the contents of the StatusBar once
+	// any requests have been made via the HTTP cache.
+	STATUS_CACHE_FORMAT_STR = " [#AAAAAA]Cache hit ratio: [::b]%.0f%%[::-][-]"
+	// STATUS_SYNCING_STR is appended to the contents of the StatusBar whilst a
+	// poll is in progress.
+	STATUS_SYNCING_STR = " [yellow::b]Syncing…[-::-]"
 	// STATUS_TIMESTAMP_FORMAT specifies the desired outputting format for any
 	// timestamps.
 	STATUS_TIMESTAMP_FORMAT = "15:04:05"
 )
 
 // StatusBar is a wrapper around the `TextView` `tview.Primitive`, and provides
-// a helper method for updating the status based upon the time of a given sync.
-// A future step may be to introduce some form of error output - i.e via a new
-// format string, and an update function which accepts an `error`.
+// helper methods for updating the status based upon the time of a given sync,
+// an error encountered whilst syncing, or the latest API rate limit and cache
+// statistics. The status of each Account is displayed on a separate line, and
+// all updates are made by the index of the Account.
 type StatusBar struct {
-	generator func(time.Time) string
+	accounts  []*accountStatus
 	Primitive *tview.TextView
 }
 
-// Create a new StatusBar complete with all state required.
-func NewStatusBar(username string, pollInterval int, initialSync time.Time) *StatusBar {
-	// Closure to capture pollInterview and Username, meaning subsequent updates
-	// only require the `lastSync` value
-	generator := func(lastSync time.Time) string {
-		return fmt.Sprintf(STATUS_FORMAT_STR, username, pollInterval, lastSync.Format(STATUS_TIMESTAMP_FORMAT))
-	}
+// accountStatus contains the status of a single Account.
+type accountStatus struct {
+	generator      func(time.Time) string
+	errorGenerator func(time.Time, error) string
+	lastSync       time.Time
+	lastError      error
+	syncing        bool
+	rateLimit      github.RateLimit
+	cacheStats     github.CacheStats
+}
 
-	return &StatusBar{
-		generator: generator,
+// Create a new StatusBar complete with all state required for each Account.
+func NewStatusBar(pollInterval int, accounts []AccountState) *StatusBar {
+	sb := &StatusBar{
+		accounts: make([]*accountStatus, len(accounts)),
 		Primitive: tview.NewTextView().
 			SetDynamicColors(true).
 			SetScrollable(false).
-			SetTextAlign(tview.AlignCenter).
-			SetText(generator(initialSync)),
+			SetTextAlign(tview.AlignCenter),
+	}
+
+	for idx, account := range accounts {
+		// Closure to capture pollInterview, Username and Host, meaning subsequent
+		// updates only require the `lastSync` value
+		username, host := account.Username, account.Host
+		sb.accounts[idx] = &accountStatus{
+			generator: func(lastSync time.Time) string {
+				return fmt.Sprintf(STATUS_FORMAT_STR, username, host, pollInterval, lastSync.Format(STATUS_TIMESTAMP_FORMAT))
+			},
+			errorGenerator: func(lastSync time.Time, err error) string {
+				return fmt.Sprintf(STATUS_ERROR_FORMAT_STR, username, host, tview.Escape(err.Error()), lastSync.Format(STATUS_TIMESTAMP_FORMAT))
+			},
+			lastSync:   account.LastSync,
+			rateLimit:  account.RateLimit,
+			cacheStats: account.CacheStats,
+		}
+	}
+
+	sb.render()
+	return sb
+}
+
+// Height returns the number of lines required to display the StatusBar.
+func (sb *StatusBar) Height() int {
+	if len(sb.accounts) == 0 {
+		return 1
 	}
+
+	return len(sb.accounts)
+}
+
+// Update the StatusBar with the latest sync time of an Account; simply updates
+// the internal TextView primitive.
+func (sb *StatusBar) Update(account int, latestSync time.Time) {
+	sb.accounts[account].lastSync = latestSync
+	sb.accounts[account].lastError = nil
+	sb.accounts[account].syncing = false
+	sb.render()
+}
+
+// UpdateError displays an error encountered whilst syncing an Account; the
+// time of the last successful sync remains visible. Any subsequent successful
+// sync - via `Update` - will clear the error.
+func (sb *StatusBar) UpdateError(account int, err error) {
+	sb.accounts[account].lastError = err
+	sb.accounts[account].syncing = false
+	sb.render()
+}
+
+// UpdateSyncing indicates that an Account is being synchronised; this is
+// cleared by the outcome - via either `Update` or `UpdateError`.
+func (sb *StatusBar) UpdateSyncing(account int) {
+	sb.accounts[account].syncing = true
+	sb.render()
 }
 
-// Update the StatusBar with the latest sync time; simply updates the internal
-// TextView primitive.
-func (sb *StatusBar) Update(latestSync time.Time) {
-	sb.Primitive.SetText(sb.generator(latestSync))
+// UpdateRateLimit displays the remaining API quota of an Account, and when it
+// will reset.
+func (sb *StatusBar) UpdateRateLimit(account int, rateLimit github.RateLimit) {
+	sb.accounts[account].rateLimit = rateLimit
+	sb.render()
+}
+
+// UpdateCacheStats displays the hit ratio of an Account's HTTP cache.
+func (sb *StatusBar) UpdateCacheStats(account int, cacheStats github.CacheStats) {
+	sb.accounts[account].cacheStats = cacheStats
+	sb.render()
+}
+
+func (sb *StatusBar) render() {
+	lines := make([]string, len(sb.accounts))
+	for idx, account := range sb.accounts {
+		lines[idx] = account.render()
+	}
+
+	sb.Primitive.SetText(strings.Join(lines, "\n"))
+}
+
+func (status *accountStatus) render() string {
+	// Errors take precedence over the standard status; the rate limit status
+	// and cache statistics are appended to either, once they're known.
+	text := status.generator(status.lastSync)
+	if status.lastError != nil {
+		text = status.errorGenerator(status.lastSync, status.lastError)
+	}
+
+	if status.rateLimit.Known() {
+		text += fmt.Sprintf(STATUS_RATE_LIMIT_FORMAT_STR,
+			status.rateLimit.Remaining, status.rateLimit.Limit, status.rateLimit.Reset.Format(STATUS_TIMESTAMP_FORMAT))
+	}
+
+	if status.cacheStats.Hits+status.cacheStats.Misses > 0 {
+		text += fmt.Sprintf(STATUS_CACHE_FORMAT_STR, status.cacheStats.HitRatio()*100)
+	}
+
+	if status.syncing {
+		text += STATUS_SYNCING_STR
+	}
+
+	return text
 }
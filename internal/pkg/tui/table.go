@@ -6,16 +6,10 @@ import (
 	"github.com/rivo/tview"
 )
 
-var (
-	pullRequestColumns = []string{
-		"Repository", "ID", "Author", "Title", "Reviewers", "Status", "Age",
-	}
-)
-
 const (
-	// EXPANDING_TABLE_COLUMN_IDX is the index of the column which should expand
-	// to take up all available spare space.
-	EXPANDING_TABLE_COLUMN_IDX = 3
+	// EXPANDING_TABLE_COLUMN is the column which should expand to take up all
+	// available spare space; if it's not displayed then the last column expands.
+	EXPANDING_TABLE_COLUMN = COLUMN_TITLE
 	// REFERENCE_COLUMN is the column of a row that's expected to contain any
 	// reference data - i.e a URL for the Pull Request associated with a row.
 	REFERENCE_COLUMN = 0
@@ -32,15 +26,25 @@ type RowCollection interface {
 // selections and events.
 type Table struct {
 	Primitive           *tview.Table
+	columns             []string
+	openKey             KeyBinding
+	refreshKey          KeyBinding
+	refresh             func()
 	currentRowReference string
 }
 
 // NewTable initialises and configures a `tview.Table` for displaying in the
 // TUI; it configures the table with sane defaults such as borders, padding,
-// selectability, and title options.
-func NewTable(title string, rows RowCollection) *Table {
+// selectability, and title options. The table displays the provided `columns`,
+// opens the selected Pull Request when `openKey` is pressed, and calls `refresh`
+// - if not nil - when `refreshKey` is pressed.
+func NewTable(title string, columns []string, openKey, refreshKey KeyBinding, refresh func(), rows RowCollection) *Table {
 	t := &Table{
-		Primitive: tview.NewTable().SetSelectable(true, false).SetBorders(true),
+		Primitive:  tview.NewTable().SetSelectable(true, false).SetBorders(true),
+		columns:    columns,
+		openKey:    openKey,
+		refreshKey: refreshKey,
+		refresh:    refresh,
 	}
 
 	t.Primitive.SetSelectionChangedFunc(t.handlerSelection).
@@ -57,15 +61,21 @@ func NewTable(title string, rows RowCollection) *Table {
 // a `RowCollection`. This function does not re-draw the table. It is expected
 // that this will happen via the Queue internal to the parent `tview.App`.
 func (t *Table) Update(rows RowCollection) {
-	for idx, title := range pullRequestColumns {
-		t.Primitive.SetCell(0, idx, tview.NewTableCell(title).
+	t.Primitive.Clear()
+	expandingIdx := len(t.columns) - 1
+	for idx, name := range t.columns {
+		if name == EXPANDING_TABLE_COLUMN {
+			expandingIdx = idx
+		}
+
+		t.Primitive.SetCell(0, idx, tview.NewTableCell(pullRequestColumns[name].title).
 			SetAlign(tview.AlignCenter).
 			SetAttributes(tcell.AttrBold).
 			SetSelectable(false))
 	}
 
 	rows.PopulateTable(t.Primitive)
-	t.expandColumn(EXPANDING_TABLE_COLUMN_IDX)
+	t.expandColumn(expandingIdx)
 }
 
 func (t *Table) expandColumn(col int) {
@@ -92,12 +102,14 @@ func (t *Table) handlerSelection(row, _ int) {
 }
 
 func (t *Table) handlerEventKey(evt *tcell.EventKey) *tcell.EventKey {
-	// Detect user keypresses; at the moment 'o' = 'Open in Browser'.
-	switch evt.Rune() {
-	case 'o':
-		if t.currentRowReference != "" {
-			browser.OpenURL(t.currentRowReference)
-		}
+	// Detect user keypresses; either 'Open in Browser', or 'Refresh'.
+	if t.openKey.Matches(evt) && t.currentRowReference != "" {
+		browser.OpenURL(t.currentRowReference)
+	}
+
+	if t.refreshKey.Matches(evt) && t.refresh != nil {
+		t.refresh()
+		return nil
 	}
 
 	return evt